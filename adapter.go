@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultAdapter 分类未指定适配器时使用的网站
+const DefaultAdapter = "fengzhansy"
+
+// CookieRequirement 网站需要的Cookie
+type CookieRequirement struct {
+	Name        string `json:"name"`        // Cookie名称
+	Description string `json:"description"` // 说明，如“门店ID”
+	Required    bool   `json:"required"`    // 缺少时是否无法获取价格
+}
+
+// SiteAdapter 超市网站适配器，负责某个网站的分类地址、页面获取和商品解析。
+// 新增超市只需实现该接口并在 init 中调用 registerAdapter
+type SiteAdapter interface {
+	// Name 适配器名称，分类配置的 adapter 字段引用该名称
+	Name() string
+	// Description 网站说明
+	Description() string
+	// CategoryURL 返回分类页面地址，分类配置了 url 时一般直接使用
+	CategoryURL(c CategoryConfig) (string, error)
+	// Fetch 获取分类页面
+	Fetch(ctx context.Context, config Config, url string) (*FetchResult, error)
+	// Parse 从分类页面解析商品
+	Parse(ctx context.Context, config Config, body string) ([]Product, error)
+	// Cookies 网站需要的Cookie
+	Cookies() []CookieRequirement
+}
+
+// adapterDebugger 可选接口，调试模式下输出页面结构等诊断信息
+type adapterDebugger interface {
+	Debug(config Config, body string)
+}
+
+// siteAdapters 已注册的适配器
+var siteAdapters = make(map[string]SiteAdapter)
+
+// registerAdapter 注册适配器，名称重复时panic
+func registerAdapter(adapter SiteAdapter) {
+	name := adapter.Name()
+	if _, ok := siteAdapters[name]; ok {
+		panic(fmt.Sprintf("适配器重复注册: %s", name))
+	}
+	siteAdapters[name] = adapter
+}
+
+// getAdapter 按名称获取适配器，名称为空时返回默认适配器
+func getAdapter(name string) (SiteAdapter, error) {
+	if name == "" {
+		name = DefaultAdapter
+	}
+	adapter, ok := siteAdapters[name]
+	if !ok {
+		return nil, fmt.Errorf("未知的网站适配器: %s（可用: %s）", name, strings.Join(adapterNames(), ", "))
+	}
+	return adapter, nil
+}
+
+// adapterNames 已注册的适配器名称
+func adapterNames() []string {
+	names := make([]string, 0, len(siteAdapters))
+	for name := range siteAdapters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// missingCookies 返回cookie字符串中缺少的必需Cookie
+func missingCookies(adapter SiteAdapter, cookie string) []CookieRequirement {
+	header := http.Header{"Cookie": {cookie}}
+	present := make(map[string]bool)
+	for _, c := range (&http.Request{Header: header}).Cookies() {
+		present[c.Name] = true
+	}
+
+	var missing []CookieRequirement
+	for _, req := range adapter.Cookies() {
+		if req.Required && !present[req.Name] {
+			missing = append(missing, req)
+		}
+	}
+	return missing
+}
+
+// fetchWithRetry 按配置的超时和重试策略获取页面，供适配器的 Fetch 使用
+func fetchWithRetry(ctx context.Context, config Config, url string) (*FetchResult, error) {
+	client := &http.Client{
+		Timeout: time.Duration(config.Timeout) * time.Second,
+	}
+	return fetchPage(ctx, client, url, config.Cookie, config.UserAgent, newRetryPolicy(&config))
+}
+
+// fetchCategoryProducts 使用分类对应的适配器获取并解析商品
+func fetchCategoryProducts(ctx context.Context, config Config, c CategoryConfig) ([]Product, error) {
+	adapter, err := getAdapter(c.Adapter)
+	if err != nil {
+		return nil, err
+	}
+	url, err := adapter.CategoryURL(c)
+	if err != nil {
+		return nil, err
+	}
+	// 门店由Cookie区分，按门店覆盖配置中的cookie
+	var store StoreConfig
+	if c.Store != "" {
+		var ok bool
+		if store, ok = config.findStore(c.Store); !ok {
+			return nil, fmt.Errorf("门店不存在: %s", c.Store)
+		}
+		config.Cookie = storeCookie(config.Cookie, adapter, store)
+	}
+	page, err := adapter.Fetch(ctx, config, url)
+	if err != nil {
+		return nil, err
+	}
+	products, err := adapter.Parse(ctx, config, page.Body)
+	if err != nil {
+		return nil, fmt.Errorf("解析页面失败: %v", err)
+	}
+	for i := range products {
+		products[i].Store = store.ID
+		products[i].StoreName = store.Name
+	}
+	// 开启详情抓取时补充详情页信息
+	fetchProductDetails(ctx, config, adapter, url, products)
+	// 按件卖的商品按名称估算重量
+	estimateWeights(config, products)
+	// 规范名称用于历史、提醒和对比时合并不同叫法的同一商品
+	assignCanonicalKeys(config, products)
+	return products, nil
+}
+
+// categoryURL 返回分类页面地址，无法生成时返回配置中的url
+func categoryURL(c CategoryConfig) string {
+	adapter, err := getAdapter(c.Adapter)
+	if err != nil {
+		return c.URL
+	}
+	url, err := adapter.CategoryURL(c)
+	if err != nil {
+		return c.URL
+	}
+	return url
+}
+
+// adapterName 分类使用的网站适配器名称，未设置时为默认适配器
+func adapterName(c CategoryConfig) string {
+	if c.Adapter == "" {
+		return DefaultAdapter
+	}
+	return c.Adapter
+}
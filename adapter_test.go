@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// stubAdapter 测试用的网站适配器，每行一个 "名称,价格"
+type stubAdapter struct{}
+
+func (stubAdapter) Name() string        { return "stub" }
+func (stubAdapter) Description() string { return "测试网站" }
+func (stubAdapter) CategoryURL(c CategoryConfig) (string, error) {
+	return "stub://" + c.ID, nil
+}
+func (stubAdapter) Fetch(ctx context.Context, config Config, url string) (*FetchResult, error) {
+	return &FetchResult{Body: "西红柿,3.98\n土豆,1.5"}, nil
+}
+func (stubAdapter) Parse(ctx context.Context, config Config, body string) ([]Product, error) {
+	var products []Product
+	for _, line := range strings.Split(body, "\n") {
+		name, price, _ := strings.Cut(line, ",")
+		p, _ := strconv.ParseFloat(price, 64)
+		products = append(products, Product{ID: name, Name: name, Price: p, PricePerJin: p, Unit: "元/斤"})
+	}
+	return products, nil
+}
+func (stubAdapter) Cookies() []CookieRequirement {
+	return []CookieRequirement{{Name: "store", Required: true}}
+}
+
+// TestSiteAdapters 测试适配器注册、分类地址生成、Cookie检查和按分类选择适配器
+func TestSiteAdapters(t *testing.T) {
+	if _, ok := siteAdapters["stub"]; !ok {
+		registerAdapter(stubAdapter{})
+	}
+
+	if _, err := getAdapter("unknown"); err == nil {
+		t.Error("未知适配器应返回错误")
+	}
+	fz, err := getAdapter("")
+	if err != nil || fz.Name() != DefaultAdapter {
+		t.Fatalf("默认适配器 = %v, err=%v", fz, err)
+	}
+
+	// 凤展超市按分类名称生成地址，与内置的默认分类地址一致
+	for _, c := range defaultCategories() {
+		generated, err := fz.CategoryURL(CategoryConfig{ID: c.ID, Name: c.Name})
+		if err != nil || generated != c.URL {
+			t.Errorf("%s 生成的地址 = %s, 期望 %s", c.Name, generated, c.URL)
+		}
+	}
+
+	missing := missingCookies(fz, "shdzarea=x; scsmdid=012")
+	if len(missing) != 1 || missing[0].Name != "shdzmdname" {
+		t.Errorf("缺少的Cookie = %+v, 期望 shdzmdname", missing)
+	}
+
+	// 不同分类使用不同的适配器
+	products, err := fetchCategoryProducts(context.Background(), Config{}, CategoryConfig{ID: "veg", Name: "蔬菜", Adapter: "stub"})
+	if err != nil || len(products) != 2 || products[1].Name != "土豆" {
+		t.Errorf("stub 适配器结果 = %+v, err=%v", products, err)
+	}
+
+	page := `<div class="index_picAD"><div><a href="/p/1"></a><h3>西红柿</h3><span class="price">￥3.98</span><span class="spec">500g</span></div></div>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, page)
+	}))
+	defer server.Close()
+	config := Config{Timeout: 5, RetryCount: 1, RetryBaseDelayMs: 1, RetryMaxDelayMs: 1}
+	products, err = fetchCategoryProducts(context.Background(), config, CategoryConfig{ID: "fruit", Name: "瓜果", URL: server.URL})
+	if err != nil || len(products) != 1 || products[0].PricePerJin != 3.98 {
+		t.Errorf("fengzhansy 适配器结果 = %+v, err=%v", products, err)
+	}
+}
@@ -0,0 +1,433 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 提醒规则的指标
+const (
+	AlertMetricPricePerJin = "price_per_jin" // 每斤价格（包装商品为包装价格）
+	AlertMetricPrice       = "price"         // 标价
+	AlertMetricChangePct   = "change_pct"    // 与24小时前相比的每斤价格变化百分比，需要启用价格历史
+)
+
+// AlertRule 价格提醒规则，例如 土豆 price_per_jin < 1.5，或 叶菜类 change_pct <= -20
+type AlertRule struct {
+	ID       string  `json:"id"`                 // 规则ID，用于去重
+	Name     string  `json:"name,omitempty"`     // 规则名称，显示在通知中
+	Product  string  `json:"product,omitempty"`  // 商品名称关键词，为空时匹配所有商品
+	Category string  `json:"category,omitempty"` // 分类ID，为空时匹配所有分类
+	Metric   string  `json:"metric,omitempty"`   // 比较的指标，默认 price_per_jin
+	Op       string  `json:"op"`                 // 比较运算符：< <= > >=
+	Value    float64 `json:"value"`              // 阈值
+
+	Watchlist string `json:"watchlist,omitempty"` // 收藏夹ID，设置后只检查该收藏夹中的商品
+
+	productKey string          // product 的规范名称，商品改名或换了叫法时仍能匹配
+	watched    map[string]bool // watchlist 中收藏的商品ID
+}
+
+// validate 检查规则配置
+func (r AlertRule) validate() error {
+	if r.ID == "" {
+		return fmt.Errorf("提醒规则缺少id")
+	}
+	switch r.Metric {
+	case "", AlertMetricPricePerJin, AlertMetricPrice, AlertMetricChangePct:
+	default:
+		return fmt.Errorf("提醒规则 %s 的metric无效: %s", r.ID, r.Metric)
+	}
+	switch r.Op {
+	case "<", "<=", ">", ">=":
+	default:
+		return fmt.Errorf("提醒规则 %s 的op无效: %q", r.ID, r.Op)
+	}
+	return nil
+}
+
+// metric 规则的指标，默认每斤价格
+func (r AlertRule) metric() string {
+	if r.Metric == "" {
+		return AlertMetricPricePerJin
+	}
+	return r.Metric
+}
+
+// title 规则在通知中的名称
+func (r AlertRule) title() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.ID
+}
+
+// matches 判断商品是否在规则范围内，名称包含关键词或规范名称相同时匹配
+func (r AlertRule) matches(categoryID, productID, name, key string) bool {
+	if r.Category != "" && r.Category != categoryID {
+		return false
+	}
+	if r.Watchlist != "" && !r.watched[productID] {
+		return false
+	}
+	return r.Product == "" || strings.Contains(name, r.Product) || (r.productKey != "" && r.productKey == key)
+}
+
+// withProductKeys 为规则设置商品关键词的规范名称
+func withProductKeys(catalog *productCatalog, rules []AlertRule) []AlertRule {
+	resolved := make([]AlertRule, len(rules))
+	for i, rule := range rules {
+		rule.productKey = catalog.Key(rule.Product)
+		resolved[i] = rule
+	}
+	return resolved
+}
+
+// compare 判断指标值是否满足条件
+func (r AlertRule) compare(v float64) bool {
+	switch r.Op {
+	case "<":
+		return v < r.Value
+	case "<=":
+		return v <= r.Value
+	case ">":
+		return v > r.Value
+	case ">=":
+		return v >= r.Value
+	}
+	return false
+}
+
+// Alert 一条触发的提醒
+type Alert struct {
+	RuleID      string    `json:"rule_id"`
+	RuleName    string    `json:"rule_name"`
+	CategoryID  string    `json:"category_id"`
+	Store       string    `json:"store,omitempty"`      // 门店ID
+	StoreName   string    `json:"store_name,omitempty"` // 门店名称
+	ProductID   string    `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	Metric      string    `json:"metric"`
+	Value       float64   `json:"value"`     // 当前指标值
+	Op          string    `json:"op"`        // 比较运算符
+	Threshold   float64   `json:"threshold"` // 阈值
+	Unit        string    `json:"unit"`
+	Message     string    `json:"message"`
+	TriggeredAt time.Time `json:"triggered_at"`
+}
+
+// Key 提醒的去重标识
+func (a Alert) Key() string {
+	id := a.ProductID
+	if id == "" {
+		id = a.ProductName
+	}
+	return a.RuleID + "|" + historyScope(a.CategoryID, a.Store) + "|" + id
+}
+
+// inStore 设置提醒所在的门店，通知文本前加上门店名称
+func (a Alert) inStore(store, storeName string) Alert {
+	a.Store = store
+	a.StoreName = storeName
+	if storeName != "" {
+		a.Message = storeName + "：" + a.Message
+	}
+	return a
+}
+
+// newAlert 生成提醒及其通知文本
+func newAlert(rule AlertRule, categoryID, productID, name, unit string, value float64, at time.Time) Alert {
+	alert := Alert{
+		RuleID:      rule.ID,
+		RuleName:    rule.title(),
+		CategoryID:  categoryID,
+		ProductID:   productID,
+		ProductName: name,
+		Metric:      rule.metric(),
+		Value:       value,
+		Op:          rule.Op,
+		Threshold:   rule.Value,
+		Unit:        unit,
+		TriggeredAt: at,
+	}
+	switch alert.Metric {
+	case AlertMetricChangePct:
+		alert.Message = fmt.Sprintf("%s 价格变化 %+.1f%% %s %.1f%%（%s）", name, value, rule.Op, rule.Value, alert.RuleName)
+	case AlertMetricPrice:
+		alert.Message = fmt.Sprintf("%s 价格 %.2f元 %s %.2f元（%s）", name, value, rule.Op, rule.Value, alert.RuleName)
+	default:
+		alert.Message = fmt.Sprintf("%s %.2f%s %s %.2f（%s）", name, value, unit, rule.Op, rule.Value, alert.RuleName)
+	}
+	return alert
+}
+
+// evaluateAlerts 对抓取结果执行提醒规则，diff 为与24小时前的对比结果，为nil时跳过 change_pct 规则
+func evaluateAlerts(rules []AlertRule, categories []Category, diff *PriceDiff, at time.Time) []Alert {
+	alerts := make([]Alert, 0)
+	for _, rule := range rules {
+		if rule.metric() == AlertMetricChangePct {
+			if diff == nil {
+				continue
+			}
+			for _, changes := range [][]ProductChange{diff.Increased, diff.Decreased} {
+				for _, c := range changes {
+					if rule.matches(c.CategoryID, c.ProductID, c.Name, c.CanonicalKey) && rule.compare(c.ChangePct) {
+						alerts = append(alerts, newAlert(rule, c.CategoryID, c.ProductID, c.Name, "%", c.ChangePct, at).inStore(c.Store, c.StoreName))
+					}
+				}
+			}
+			continue
+		}
+
+		for _, category := range categories {
+			if category.Status == CategoryStatusFailed {
+				continue
+			}
+			for _, p := range category.Products {
+				if !rule.matches(category.ID, p.ID, p.Name, p.CanonicalKey) {
+					continue
+				}
+				value := p.PricePerJin
+				if rule.metric() == AlertMetricPrice {
+					value = p.Price
+				}
+				// 无法计算价格的商品不参与比较
+				if value <= 0 {
+					continue
+				}
+				if rule.compare(value) {
+					alerts = append(alerts, newAlert(rule, category.ID, p.ID, p.Name, p.Unit, value, at).inStore(category.Store, category.StoreName))
+				}
+			}
+		}
+	}
+	return alerts
+}
+
+// alertState 已发送提醒的记录，用于去重
+type alertState struct {
+	Sent map[string]time.Time `json:"sent"` // 提醒Key -> 最近一次发送时间
+}
+
+// loadAlertState 读取提醒记录，文件不存在时返回空记录
+func loadAlertState(path string) (*alertState, error) {
+	state := &alertState{Sent: make(map[string]time.Time)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取提醒记录失败: %v", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("解析提醒记录失败: %v", err)
+	}
+	if state.Sent == nil {
+		state.Sent = make(map[string]time.Time)
+	}
+	return state, nil
+}
+
+// save 保存提醒记录
+func (s *alertState) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("创建提醒记录目录失败: %v", err)
+	}
+	// 先写临时文件再重命名，避免写入中断损坏记录
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("保存提醒记录失败: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// filter 返回需要发送的提醒：之前没有触发过，或距上次发送已超过去重时间。
+// scope 中不再触发的提醒会被清除，下次触发时重新发送
+func (s *alertState) filter(alerts []Alert, scope func(key string) bool, dedup time.Duration, now time.Time) []Alert {
+	active := make(map[string]bool, len(alerts))
+	var pending []Alert
+	for _, a := range alerts {
+		key := a.Key()
+		if active[key] {
+			continue
+		}
+		active[key] = true
+		if sent, ok := s.Sent[key]; ok && now.Sub(sent) < dedup {
+			continue
+		}
+		pending = append(pending, a)
+	}
+
+	for key := range s.Sent {
+		if !active[key] && scope(key) {
+			delete(s.Sent, key)
+		}
+	}
+	return pending
+}
+
+// alertMu 保证同一进程内提醒记录的读写不会交错
+var alertMu sync.Mutex
+
+// checkAlerts 对一批分类的抓取结果执行提醒规则并发送通知，返回发送的提醒
+func checkAlerts(ctx context.Context, config Config, categories []Category) ([]Alert, error) {
+	alerts := config.Alerts
+	if len(alerts.Rules) == 0 || len(alerts.Notifiers) == 0 {
+		return nil, nil
+	}
+
+	// change_pct 规则需要与24小时前的价格对比
+	var diff *PriceDiff
+	store, err := getHistoryStore(config.History)
+	if err != nil {
+		return nil, err
+	}
+	if store != nil {
+		diff, err = diffAgainstHistory(store, PageData{Categories: categories}, time.Now().Add(-24*time.Hour))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rules, err := withWatchlists(config.Watchlists, withProductKeys(catalogFor(config), alerts.Rules))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	triggered := evaluateAlerts(rules, categories, diff, now)
+
+	alertMu.Lock()
+	defer alertMu.Unlock()
+
+	state, err := loadAlertState(alerts.StatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// 只清除本次检查的分类中不再触发的提醒
+	checked := make(map[string]bool)
+	for _, c := range categories {
+		if c.Status != CategoryStatusFailed {
+			checked[historyScope(c.ID, c.Store)] = true
+		}
+	}
+	scope := func(key string) bool {
+		parts := strings.SplitN(key, "|", 3)
+		return len(parts) == 3 && checked[parts[1]]
+	}
+	pending := state.filter(triggered, scope, time.Duration(alerts.DedupHours)*time.Hour, now)
+	if len(pending) == 0 {
+		return nil, state.save(alerts.StatePath)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Message < pending[j].Message })
+
+	// 至少一个通知渠道发送成功才记录为已发送
+	var errs []string
+	delivered := false
+	for _, cfg := range alerts.Notifiers {
+		notifier, err := newNotifier(cfg)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if err := notifier.Notify(ctx, pending); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", notifier.Name(), err))
+			continue
+		}
+		delivered = true
+	}
+	if delivered {
+		for _, a := range pending {
+			state.Sent[a.Key()] = now
+		}
+	}
+	if err := state.save(alerts.StatePath); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return pending, fmt.Errorf("发送提醒失败: %s", strings.Join(errs, "; "))
+	}
+	return pending, nil
+}
+
+// notifyAlertsAsync 对本次新抓取的分类在后台检查一次提醒，不阻塞响应；来自缓存的分类在抓取时已经检查过。
+// 只在常驻的 serve 模式中使用，其他模式进程随时退出，由 alerts 命令检查
+func notifyAlertsAsync(config Config, categories []Category) {
+	if len(config.Alerts.Rules) == 0 || len(config.Alerts.Notifiers) == 0 {
+		return
+	}
+	var fresh []Category
+	for _, c := range categories {
+		if c.Status == CategoryStatusOK && !c.FromCache {
+			fresh = append(fresh, c)
+		}
+	}
+	if len(fresh) == 0 {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if _, err := checkAlerts(ctx, config, fresh); err != nil {
+			fmt.Printf("检查价格提醒失败: %v\n", err)
+		}
+	}()
+}
+
+// runAlertsMode 抓取所有分类并检查提醒，适合定时任务调用
+func runAlertsMode(args []string) {
+	flags := flag.NewFlagSet("alerts", flag.ExitOnError)
+	test := flags.Bool("test", false, "向所有通知渠道发送一条测试消息")
+	flags.Parse(args)
+
+	config, err := LoadConfig("config.json")
+	if err != nil {
+		fmt.Printf("加载配置文件失败: %v\n", err)
+		return
+	}
+	ctx := context.Background()
+
+	if *test {
+		alert := Alert{RuleID: "test", RuleName: "测试", Message: "这是一条测试提醒", TriggeredAt: time.Now()}
+		for _, cfg := range config.Alerts.Notifiers {
+			notifier, err := newNotifier(cfg)
+			if err == nil {
+				err = notifier.Notify(ctx, []Alert{alert})
+			}
+			if err != nil {
+				fmt.Printf("❌ %s: %v\n", cfg.Type, err)
+			} else {
+				fmt.Printf("✅ %s\n", cfg.Type)
+			}
+		}
+		return
+	}
+
+	// 提醒检查所有门店
+	stores, _ := config.selectStores(AllStores)
+	data := fetchStoresConcurrently(ctx, *config, stores)
+
+	sent, err := checkAlerts(ctx, *config, data.Categories)
+	for _, a := range sent {
+		fmt.Println("🔔", a.Message)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if len(sent) == 0 {
+		fmt.Println("没有新的价格提醒")
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestAlertRules 测试提醒规则匹配和去重
+func TestAlertRules(t *testing.T) {
+	rules := []AlertRule{
+		{ID: "potato", Product: "土豆", Op: "<", Value: 1.5},
+		{ID: "expensive", Category: "mushroom", Metric: AlertMetricPrice, Op: ">=", Value: 10},
+		{ID: "drop", Metric: AlertMetricChangePct, Op: "<=", Value: -20},
+	}
+	now := time.Now()
+	categories := []Category{
+		{ID: "root-vegetable", Status: CategoryStatusOK, Products: []Product{
+			{ID: "p1", Name: "黄心土豆", Price: 1.2, PricePerJin: 1.2, Unit: "元/斤"},
+			{ID: "p2", Name: "土豆", Price: 1.8, PricePerJin: 1.8, Unit: "元/斤"},
+			{ID: "p3", Name: "紫薯", Price: 12, PricePerJin: 6, Unit: "元/斤"},
+		}},
+		{ID: "mushroom", Status: CategoryStatusOK, Products: []Product{
+			{ID: "m1", Name: "松茸", Price: 19.9, PricePerJin: 39.8, Unit: "元/斤"},
+		}},
+	}
+	diff := &PriceDiff{Decreased: []ProductChange{
+		{CategoryID: "leaf-vegetable", ProductID: "l1", Name: "菠菜", ChangePct: -25},
+		{CategoryID: "leaf-vegetable", ProductID: "l2", Name: "油麦菜", ChangePct: -10},
+	}}
+
+	alerts := evaluateAlerts(rules, categories, diff, now)
+	var keys []string
+	for _, a := range alerts {
+		keys = append(keys, a.Key())
+	}
+	want := "potato|root-vegetable|p1,expensive|mushroom|m1,drop|leaf-vegetable|l1"
+	if got := strings.Join(keys, ","); got != want {
+		t.Errorf("触发的提醒 = %s, 期望 %s", got, want)
+	}
+	// 没有历史对比结果时跳过 change_pct 规则
+	if n := len(evaluateAlerts(rules, categories, nil, now)); n != 2 {
+		t.Errorf("无对比结果时触发 %d 条, 期望2条", n)
+	}
+
+	for _, rule := range []AlertRule{{Op: "<"}, {ID: "x", Op: "=="}, {ID: "x", Metric: "weight", Op: "<"}} {
+		if err := rule.validate(); err == nil {
+			t.Errorf("规则 %+v 应校验失败", rule)
+		}
+	}
+
+	// 去重：去重时间内不重复发送，条件解除后再次触发立即发送
+	state := &alertState{Sent: make(map[string]time.Time)}
+	all := func(string) bool { return true }
+	dedup := 24 * time.Hour
+	if n := len(state.filter(alerts, all, dedup, now)); n != 3 {
+		t.Errorf("首次发送 %d 条, 期望3条", n)
+	}
+	for _, a := range alerts {
+		state.Sent[a.Key()] = now
+	}
+	if n := len(state.filter(alerts, all, dedup, now.Add(time.Hour))); n != 0 {
+		t.Errorf("去重时间内发送 %d 条, 期望0条", n)
+	}
+	if n := len(state.filter(alerts, all, dedup, now.Add(25*time.Hour))); n != 3 {
+		t.Errorf("超过去重时间后发送 %d 条, 期望3条", n)
+	}
+	state.filter(alerts[1:], all, dedup, now.Add(2*time.Hour))
+	if n := len(state.filter(alerts, all, dedup, now.Add(3*time.Hour))); n != 1 {
+		t.Errorf("条件解除后再次触发发送 %d 条, 期望1条", n)
+	}
+}
+
+// TestCheckAlerts 测试检查提醒并通过webhook发送，重复检查不再发送
+func TestCheckAlerts(t *testing.T) {
+	var received []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Text   string  `json:"text"`
+			Alerts []Alert `json:"alerts"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		received = append(received, payload.Text)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	config := Config{
+		History: HistoryConfig{Driver: HistoryDriverNone},
+		Alerts: AlertConfig{
+			Rules:      []AlertRule{{ID: "potato", Product: "土豆", Op: "<", Value: 1.5}},
+			Notifiers:  []NotifierConfig{{Type: NotifierWebhook, URL: server.URL}},
+			DedupHours: 24,
+			StatePath:  filepath.Join(t.TempDir(), "alert_state.json"),
+		},
+	}
+	categories := []Category{{ID: "root-vegetable", Status: CategoryStatusOK, Products: []Product{
+		{ID: "p1", Name: "土豆", Price: 1.2, PricePerJin: 1.2, Unit: "元/斤"},
+	}}}
+
+	for i, wantSent := range []int{1, 0} {
+		sent, err := checkAlerts(context.Background(), config, categories)
+		if err != nil {
+			t.Fatalf("第%d次检查失败: %v", i+1, err)
+		}
+		if len(sent) != wantSent {
+			t.Errorf("第%d次检查发送 %d 条, 期望 %d 条", i+1, len(sent), wantSent)
+		}
+	}
+	if len(received) != 1 || !strings.Contains(received[0], "土豆 1.20元/斤 < 1.50") {
+		t.Errorf("webhook收到 %q", received)
+	}
+}
+
+// TestNotifyAlertsOncePerPage 测试抓取分类时不再单独发送提醒，一次请求的新抓取分类只检查一次，来自缓存的分类不检查
+func TestNotifyAlertsOncePerPage(t *testing.T) {
+	if _, ok := siteAdapters["stub"]; !ok {
+		registerAdapter(stubAdapter{})
+	}
+	requests := make(chan []Alert, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Alerts []Alert `json:"alerts"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		requests <- payload.Alerts
+	}))
+	defer server.Close()
+
+	config := Config{
+		History: HistoryConfig{Driver: HistoryDriverNone},
+		Alerts: AlertConfig{
+			Rules:      []AlertRule{{ID: "cheap", Op: "<", Value: 5}},
+			Notifiers:  []NotifierConfig{{Type: NotifierWebhook, URL: server.URL}},
+			DedupHours: 24,
+			StatePath:  filepath.Join(t.TempDir(), "alert_state.json"),
+		},
+	}
+	veg := fetchCategory(context.Background(), config, CategoryConfig{ID: "veg", Name: "蔬菜", Adapter: "stub"})
+	select {
+	case alerts := <-requests:
+		t.Fatalf("抓取分类时不应发送提醒: %+v", alerts)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	fruit, cached := veg, veg
+	fruit.ID = "fruit"
+	cached.ID, cached.FromCache = "cached", true
+	notifyAlertsAsync(config, []Category{veg, fruit, cached})
+	select {
+	case alerts := <-requests:
+		if len(alerts) != 4 {
+			t.Errorf("一次检查应发送两个新抓取分类的4条提醒, 实际 %+v", alerts)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("没有发送提醒")
+	}
+	select {
+	case alerts := <-requests:
+		t.Errorf("一次请求只应检查一次, 又收到 %+v", alerts)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/base64"
+	"encoding/hex"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// embeddedAssets 编译进程序的页面模板和静态文件，部署时不需要再附带这些文件
+//
+//go:embed product_list.html static
+var embeddedAssets embed.FS
+
+// productListTemplate 内置的商品列表模板，启动时解析一次
+var productListTemplate = template.Must(parseProductListTemplate(embeddedAssets))
+
+// assetFS 返回模板和静态文件所在的文件系统，配置了 template_dir 时从该目录读取
+func assetFS(templateDir string) fs.FS {
+	if templateDir != "" {
+		return os.DirFS(templateDir)
+	}
+	return embeddedAssets
+}
+
+// parseProductListTemplate 从文件系统解析商品列表页面模板
+func parseProductListTemplate(fsys fs.FS) (*template.Template, error) {
+	return template.New("product_list.html").Option("missingkey=error").Funcs(templateFuncs).ParseFS(fsys, "product_list.html")
+}
+
+// productListTemplateFor 返回要使用的模板：内置模板只解析一次，
+// 设置了 template_dir 时每次重新解析，修改模板后刷新页面即可看到效果
+func productListTemplateFor(templateDir string) (*template.Template, error) {
+	if templateDir == "" {
+		return productListTemplate, nil
+	}
+	return parseProductListTemplate(assetFS(templateDir))
+}
+
+// assetVersions 内置静态文件的内容摘要，用于生成带版本号的URL
+var assetVersions sync.Map
+
+// assetPath 返回带版本号的静态文件路径，如 css/app.css?v=1a2b3c4d，
+// 内容变化后URL随之变化，浏览器可以长期缓存
+func assetPath(name string) string {
+	if v, ok := assetVersions.Load(name); ok {
+		return name + "?v=" + v.(string)
+	}
+	data, err := fs.ReadFile(embeddedAssets, "static/"+name)
+	if err != nil {
+		return name
+	}
+	sum := sha256.Sum256(data)
+	version := hex.EncodeToString(sum[:4])
+	assetVersions.Store(name, version)
+	return name + "?v=" + version
+}
+
+// cssFontPattern CSS中 @font-face 引用的字体文件，如 url('../fonts/x.woff2?v=4.7.0') format('woff2')
+var cssFontPattern = regexp.MustCompile(`,?url\('\.\./(fonts/[^'?]+)[^']*'\) format\('([\w-]+)'\)`)
+
+// inlinedCSS 已内联的CSS，字体编码后较大，只生成一次
+var inlinedCSS sync.Map
+
+// inlineCSS 内置CSS文件的内容，没有静态文件路由（如阿里云函数）时直接写入页面。
+// 引用的 woff2 字体改为 data URI，其他格式的字体去掉，页面不依赖外部文件
+func inlineCSS(name string) template.CSS {
+	if css, ok := inlinedCSS.Load(name); ok {
+		return css.(template.CSS)
+	}
+	data, err := fs.ReadFile(embeddedAssets, "static/"+name)
+	if err != nil {
+		return ""
+	}
+	css := cssFontPattern.ReplaceAllStringFunc(string(data), func(ref string) string {
+		m := cssFontPattern.FindStringSubmatch(ref)
+		font, err := fs.ReadFile(embeddedAssets, "static/"+m[1])
+		if m[2] != "woff2" || err != nil {
+			return ""
+		}
+		prefix := ""
+		if strings.HasPrefix(ref, ",") {
+			prefix = ","
+		}
+		return prefix + "url(data:font/woff2;base64," + base64.StdEncoding.EncodeToString(font) + ") format('woff2')"
+	})
+	inlinedCSS.Store(name, template.CSS(css))
+	return template.CSS(css)
+}
+
+// staticHandler 提供 /static/ 下的静态文件
+func staticHandler(templateDir string) http.Handler {
+	static, err := fs.Sub(assetFS(templateDir), "static")
+	if err != nil {
+		return http.NotFoundHandler()
+	}
+	files := http.StripPrefix("/static/", http.FileServerFS(static))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 不列出目录内容
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		if templateDir == "" && r.URL.Query().Get("v") != "" {
+			// 带版本号的内置文件内容不会变化
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		} else {
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestEmbeddedAssets 测试内置模板引用的静态文件都存在，以及 template_dir 覆盖内置模板
+func TestEmbeddedAssets(t *testing.T) {
+	out, err := renderProductList("", PageData{StaticURL: "/static/", Errors: []CategoryError{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "cdn.tailwindcss.com") || strings.Contains(out, "cdn.jsdelivr.net") {
+		t.Error("页面不应再引用外部CDN")
+	}
+	for _, name := range []string{"css/tailwind.css", "css/font-awesome.min.css"} {
+		link := "/static/" + assetPath(name)
+		if !strings.Contains(out, `href="`+link+`"`) {
+			t.Errorf("页面中缺少 %s", link)
+		}
+		if _, err := embeddedAssets.ReadFile("static/" + name); err != nil {
+			t.Errorf("内置文件缺少 %s: %v", name, err)
+		}
+	}
+
+	// 没有静态文件路由（阿里云函数）时内联CSS和图标字体，不引用任何外部文件
+	out, err = renderProductList("", PageData{Errors: []CategoryError{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ref := range []string{"/static/", `href="http`, `src="http`, "url(http", "../fonts/"} {
+		if strings.Contains(out, ref) {
+			t.Errorf("未设置 static_url 时页面不应引用外部文件: %s", ref)
+		}
+	}
+	for _, want := range []string{".space-y-2 > :not([hidden])", ".fa-heart:before", "src:url(data:font/woff2;base64,d09GMg"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("内联的CSS中缺少 %q", want)
+		}
+	}
+
+	// 带版本号的内置文件可以长期缓存
+	rec := httptest.NewRecorder()
+	staticHandler("").ServeHTTP(rec, httptest.NewRequest("GET", "/static/"+assetPath("css/tailwind.css"), nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/css") || !strings.Contains(rec.Header().Get("Cache-Control"), "immutable") {
+		t.Errorf("静态文件响应 %d %v", rec.Code, rec.Header())
+	}
+
+	// template_dir 中的模板和静态文件优先，每次请求重新读取
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "static"), 0o755)
+	os.WriteFile(filepath.Join(dir, "product_list.html"), []byte(`<h1>{{len .Categories}} {{price 1}}</h1>`), 0o644)
+	os.WriteFile(filepath.Join(dir, "static", "app.css"), []byte("body{}"), 0o644)
+	out, err = renderProductList(dir, PageData{Categories: []Category{{}}})
+	if err != nil || out != "<h1>1 1.00</h1>" {
+		t.Errorf("覆盖模板输出 %q, err=%v", out, err)
+	}
+	os.WriteFile(filepath.Join(dir, "product_list.html"), []byte(`<h1>v2</h1>`), 0o644)
+	if out, _ = renderProductList(dir, PageData{}); out != "<h1>v2</h1>" {
+		t.Errorf("修改后的覆盖模板输出 %q", out)
+	}
+	rec = httptest.NewRecorder()
+	staticHandler(dir).ServeHTTP(rec, httptest.NewRequest("GET", "/static/app.css", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "body{}" {
+		t.Errorf("覆盖目录静态文件响应 %d %q", rec.Code, rec.Body.String())
+	}
+}
@@ -28,16 +28,15 @@ cd dist
 # 复制配置文件
 cp ../config.json . 2>/dev/null || echo "⚠️  config.json 不存在，使用示例配置"
 cp ../config.example.json . 2>/dev/null || echo "⚠️  config.example.json 不存在"
-cp ../product_list.html . 2>/dev/null || echo "⚠️  product_list.html 不存在"
 
 # Linux包
-tar -czf vegetable-price-linux-amd64.tar.gz vegetable-price-linux-amd64 config.json config.example.json product_list.html  2>/dev/null || tar -czf vegetable-price-linux-amd64.tar.gz vegetable-price-linux-amd64
+tar -czf vegetable-price-linux-amd64.tar.gz vegetable-price-linux-amd64 config.json config.example.json  2>/dev/null || tar -czf vegetable-price-linux-amd64.tar.gz vegetable-price-linux-amd64
 
 # Windows包
-zip -r vegetable-price-windows-amd64.zip vegetable-price-windows-amd64.exe config.json config.example.json product_list.html  2>/dev/null || zip -r vegetable-price-windows-amd64.zip vegetable-price-windows-amd64.exe
+zip -r vegetable-price-windows-amd64.zip vegetable-price-windows-amd64.exe config.json config.example.json  2>/dev/null || zip -r vegetable-price-windows-amd64.zip vegetable-price-windows-amd64.exe
 
 # macOS包
-tar -czf vegetable-price-darwin-amd64.tar.gz vegetable-price-darwin-amd64 config.json config.example.json product_list.html  2>/dev/null || tar -czf vegetable-price-darwin-amd64.tar.gz vegetable-price-darwin-amd64
+tar -czf vegetable-price-darwin-amd64.tar.gz vegetable-price-darwin-amd64 config.json config.example.json  2>/dev/null || tar -czf vegetable-price-darwin-amd64.tar.gz vegetable-price-darwin-amd64
 
 # 当前平台包
 if [[ "$OSTYPE" == "linux-gnu"* ]]; then
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// productCache 进程内的分类结果缓存
+var productCache = newCategoryCache()
+
+// categoryCache 按分类缓存抓取结果
+// 缓存未过期时直接返回；过期但仍在可用期内时先返回旧数据并在后台刷新；
+// 同一分类同时只有一个刷新请求，其余调用方等待该请求的结果
+type categoryCache struct {
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+// cacheEntry 单个分类的缓存
+type cacheEntry struct {
+	category   Category      // 最近一次成功的抓取结果
+	hasData    bool          // 是否已有成功的抓取结果
+	refreshing *cacheRefresh // 进行中的刷新，没有时为nil
+}
+
+// cacheRefresh 一次进行中的刷新
+type cacheRefresh struct {
+	done   chan struct{}
+	result Category
+}
+
+func newCategoryCache() *categoryCache {
+	return &categoryCache{entries: make(map[string]*cacheEntry)}
+}
+
+// fetchBudget 单个分类抓取（含全部重试）的最长耗时，用于后台刷新
+func fetchBudget(config Config) time.Duration {
+	perAttempt := time.Duration(config.Timeout) * time.Second
+	retries := time.Duration(config.RetryCount)
+	return perAttempt*(retries+1) + time.Duration(config.RetryMaxDelayMs)*time.Millisecond*retries
+}
+
+// Get 获取分类数据，优先使用缓存
+func (cc *categoryCache) Get(ctx context.Context, config Config, c CategoryConfig) Category {
+	ttl := time.Duration(config.CacheTTLSeconds) * time.Second
+	if ttl <= 0 {
+		// 缓存已关闭
+		return fetchCategory(ctx, config, c)
+	}
+	staleTTL := time.Duration(config.CacheStaleSeconds) * time.Second
+
+	key := c.ID + "|" + c.Adapter + "|" + c.URL + "|" + c.Store
+	cc.mu.Lock()
+	entry, ok := cc.entries[key]
+	if !ok {
+		entry = &cacheEntry{}
+		cc.entries[key] = entry
+	}
+
+	now := time.Now()
+	if entry.hasData {
+		age := now.Sub(entry.category.FetchedAt)
+		if age < ttl {
+			cached := entry.category
+			cc.mu.Unlock()
+			return markCached(cached, now)
+		}
+		if age < ttl+staleTTL {
+			// 先返回旧数据，后台刷新
+			cc.startRefreshLocked(entry, config, c)
+			cached := entry.category
+			cc.mu.Unlock()
+			return markCached(cached, now)
+		}
+	}
+
+	refresh := cc.startRefreshLocked(entry, config, c)
+	cc.mu.Unlock()
+
+	select {
+	case <-refresh.done:
+		if refresh.result.Status == CategoryStatusOK {
+			return refresh.result
+		}
+		return cc.staleOrFailed(entry, refresh.result)
+	case <-ctx.Done():
+		failed := Category{
+			ID:         c.ID,
+			Name:       c.Name,
+			Adapter:    adapterName(c),
+			Store:      c.Store,
+			StoreName:  storeName(config, c.Store),
+			Products:   make([]Product, 0),
+			Status:     CategoryStatusFailed,
+			Error:      "等待抓取结果超时: " + ctx.Err().Error(),
+			DurationMs: time.Since(now).Milliseconds(),
+			FetchedAt:  now,
+		}
+		return cc.staleOrFailed(entry, failed)
+	}
+}
+
+// startRefreshLocked 启动后台刷新，已有进行中的刷新时直接复用，调用前需持有锁
+func (cc *categoryCache) startRefreshLocked(entry *cacheEntry, config Config, c CategoryConfig) *cacheRefresh {
+	if entry.refreshing != nil {
+		return entry.refreshing
+	}
+
+	refresh := &cacheRefresh{done: make(chan struct{})}
+	entry.refreshing = refresh
+
+	go func() {
+		// 刷新不跟随单个请求取消，结果留给后续请求使用
+		ctx, cancel := context.WithTimeout(context.Background(), fetchBudget(config))
+		defer cancel()
+		result := fetchCategory(ctx, config, c)
+
+		cc.mu.Lock()
+		if result.Status == CategoryStatusOK {
+			entry.category = result
+			entry.hasData = true
+		}
+		refresh.result = result
+		entry.refreshing = nil
+		cc.mu.Unlock()
+		close(refresh.done)
+	}()
+
+	return refresh
+}
+
+// staleOrFailed 抓取失败时，有旧数据则返回旧数据并标记为stale
+func (cc *categoryCache) staleOrFailed(entry *cacheEntry, failed Category) Category {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if !entry.hasData {
+		return failed
+	}
+	stale := markCached(entry.category, time.Now())
+	stale.Status = CategoryStatusStale
+	stale.Error = failed.Error
+	return stale
+}
+
+// markCached 标记数据来自缓存并计算缓存时长
+func markCached(category Category, now time.Time) Category {
+	category.FromCache = true
+	category.CacheAgeSeconds = int64(now.Sub(category.FetchedAt).Seconds())
+	return category
+}
+
+// CacheAgeText 缓存时长的显示文本
+func (c Category) CacheAgeText() string {
+	switch {
+	case c.CacheAgeSeconds < 60:
+		return fmt.Sprintf("%d秒前", c.CacheAgeSeconds)
+	case c.CacheAgeSeconds < 3600:
+		return fmt.Sprintf("%d分钟前", c.CacheAgeSeconds/60)
+	default:
+		return fmt.Sprintf("%d小时前", c.CacheAgeSeconds/3600)
+	}
+}
+
+// maxCacheAge 页面中最旧数据的缓存时长（秒）
+func (d PageData) maxCacheAge() int64 {
+	var age int64
+	for _, c := range d.Categories {
+		if c.CacheAgeSeconds > age {
+			age = c.CacheAgeSeconds
+		}
+	}
+	return age
+}
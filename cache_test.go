@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCategoryCache 测试缓存命中、并发去重和失败时返回旧数据
+func TestCategoryCache(t *testing.T) {
+	var hits atomic.Int32
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if failing.Load() {
+			http.NotFound(w, r)
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprint(w, `<div class="index_picAD"><div><a href="p1"></a><h3>西红柿</h3><span class="price">￥3.98</span><span class="spec">500g</span></div></div>`)
+	}))
+	defer server.Close()
+
+	config := Config{Cookie: "a=b", Timeout: 5, CacheTTLSeconds: 60, CacheStaleSeconds: 60}
+	category := CategoryConfig{ID: "fruit-vegetable", Name: "瓜果花菜类", URL: server.URL}
+	cache := newCategoryCache()
+
+	// 并发请求只抓取一次
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if c := cache.Get(context.Background(), config, category); c.Status != CategoryStatusOK || len(c.Products) != 1 {
+				t.Errorf("首次获取结果 status=%s products=%d", c.Status, len(c.Products))
+			}
+		}()
+	}
+	wg.Wait()
+	if hits.Load() != 1 {
+		t.Errorf("并发请求抓取了%d次, 期望1次", hits.Load())
+	}
+
+	// 未过期时命中缓存
+	if c := cache.Get(context.Background(), config, category); !c.FromCache || hits.Load() != 1 {
+		t.Errorf("应命中缓存, from_cache=%v hits=%d", c.FromCache, hits.Load())
+	}
+
+	// 超过可用期且抓取失败时返回旧数据
+	failing.Store(true)
+	cache.mu.Lock()
+	for _, entry := range cache.entries {
+		entry.category.FetchedAt = time.Now().Add(-3 * time.Minute)
+	}
+	cache.mu.Unlock()
+	c := cache.Get(context.Background(), config, category)
+	if c.Status != CategoryStatusStale || len(c.Products) != 1 || c.Error == "" {
+		t.Errorf("抓取失败时应返回旧数据, status=%s products=%d error=%q", c.Status, len(c.Products), c.Error)
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// CatalogConfig 商品名称规范化配置，与内置词典合并
+type CatalogConfig struct {
+	Synonyms   map[string][]string `json:"synonyms,omitempty"`    // 规范名称 -> 其他叫法，如 "西红柿": ["番茄"]
+	NoiseWords []string            `json:"noise_words,omitempty"` // 名称中额外去掉的营销词
+
+	SuffixModifiers []string `json:"suffix_modifiers,omitempty"` // 额外的品种、产地前缀，名称为前缀加词条时按词条归类
+}
+
+// defaultSynonyms 内置的同物异名词典，键为规范名称
+var defaultSynonyms = map[string][]string{
+	"西红柿": {"番茄", "洋柿子"},
+	"圣女果": {"小番茄", "樱桃番茄", "小西红柿", "千禧果"},
+	"土豆":  {"马铃薯", "洋芋", "山药蛋"},
+	"红薯":  {"地瓜", "番薯", "白薯", "红苕"},
+	"香菜":  {"芫荽", "芫茜"},
+	"包菜":  {"卷心菜", "圆白菜", "洋白菜", "甘蓝", "莲花白"},
+	"西兰花": {"绿菜花", "青花菜", "西蓝花"},
+	"花菜":  {"菜花", "花椰菜"},
+	"玉米":  {"苞米", "玉茭"},
+	"油麦菜": {"莜麦菜"},
+	"空心菜": {"蕹菜", "通菜"},
+	"黄瓜":  {"青瓜"},
+	"胡萝卜": {"红萝卜"},
+	"蒜苔":  {"蒜薹"},
+	"豆角":  {"四季豆", "芸豆"},
+	"山药":  {"淮山", "铁棍山药"},
+	"木耳":  {"黑木耳"},
+	"生姜":  {"鲜姜", "老姜"},
+	"青椒":  {"菜椒"},
+	"茄子":  {"长茄", "圆茄"},
+	"香菇":  {"冬菇"},
+	"娃娃菜": {"微型大白菜"},
+	"菠菜":  {"赤根菜"},
+}
+
+// defaultSuffixModifiers 品种、产地和种植方式的前缀，名称为这些前缀加词条时按词条归类，如 普罗旺斯番茄 -> 西红柿。
+// 颜色等其他前缀常常是另一种菜（紫甘蓝、白芸豆、黄花菜），不按后缀归类
+var defaultSuffixModifiers = []string{
+	"有机", "进口", "高山", "大棚", "露地", "水果", "沙瓤", "普罗旺斯", "寿光", "山东", "云南", "海南", "东北", "新疆", "甘肃", "内蒙古",
+}
+
+// defaultNoiseWords 名称中常见的营销词，不影响是什么商品
+var defaultNoiseWords = []string{
+	"精品", "精选", "优选", "优质", "新鲜", "本地", "特价", "特级", "一级", "散装", "盒装", "袋装", "净菜",
+	"农家", "绿色", "当季", "时令", "现摘", "新品", "促销", "限时", "秒杀", "爆款", "热卖", "推荐", "基地直供", "产地直发",
+}
+
+// nameBracketPattern 名称中括号及其中的内容，如 【精品】、(约500g)
+var nameBracketPattern = regexp.MustCompile(`【[^】]*】|\[[^\]]*\]|\([^)]*\)|〔[^〕]*〕|「[^」]*」|《[^》]*》|<[^>]*>`)
+
+// nameWeightPattern 名称中的重量、件数片段，如 约500g、2斤装、250g*2、/袋
+var nameWeightPattern = regexp.MustCompile(`约?\s*[\d.]+\s*(?:kg|g|ml|l|千克|公斤|克|斤|两|毫升|升|个|只|袋|盒|包|根|把|颗|棵|份)(?:\s*[*×x/]\s*\d*\s*[袋盒包个只份]?)?(?:左右|装)?` +
+	`|[一二两三四五六七八九十半]+(?:千克|公斤|斤|个|只|袋|盒|包|根|把|颗|棵|份)装?` +
+	`|/\s*[袋盒包个只份斤根把颗棵]|约|左右`)
+
+// nameSeparatorPattern 规范化后去掉的空白和标点
+var nameSeparatorPattern = regexp.MustCompile(`[\s\p{P}\p{S}]+`)
+
+// productCatalog 编译后的名称词典
+type productCatalog struct {
+	canonical  map[string]string // 名称或别名 -> 规范名称
+	terms      []string          // 按后缀匹配的词条（至少两个字），按长度从长到短
+	modifiers  []string          // 名称中词条前允许的前缀
+	noiseWords []string
+}
+
+// newProductCatalog 合并内置词典和配置，配置中的别名优先。
+// 同一别名在配置中属于多个规范名称时返回错误
+func newProductCatalog(config CatalogConfig) (*productCatalog, error) {
+	c := &productCatalog{canonical: make(map[string]string)}
+	add := func(synonyms map[string][]string) {
+		for name, aliases := range synonyms {
+			c.canonical[normalizeProductName(name, nil)] = name
+			for _, alias := range aliases {
+				c.canonical[normalizeProductName(alias, nil)] = name
+			}
+		}
+	}
+	add(defaultSynonyms)
+
+	// 配置中的名称按排序处理，出错时的提示稳定
+	names := make([]string, 0, len(config.Synonyms))
+	for name := range config.Synonyms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	owner := make(map[string]string)
+	for _, name := range names {
+		path := fmt.Sprintf("catalog.synonyms.%s", name)
+		if normalizeProductName(name, nil) == "" {
+			return nil, fmt.Errorf("%s: 规范名称不能为空", path)
+		}
+		for _, alias := range append([]string{name}, config.Synonyms[name]...) {
+			key := normalizeProductName(alias, nil)
+			if key == "" {
+				return nil, fmt.Errorf("%s: 别名 %q 规范化后为空", path, alias)
+			}
+			if prev, ok := owner[key]; ok && prev != name {
+				return nil, fmt.Errorf("%s: %q 已经是 %s 的别名", path, alias, prev)
+			}
+			owner[key] = name
+		}
+	}
+	// 配置把内置的规范名称写成别名时，内置词典中它的别名也一起归到配置的规范名称，
+	// 如 "番茄": ["西红柿"] 时洋柿子也归为番茄；配置中直接写出的叫法随后覆盖
+	for _, name := range names {
+		for _, alias := range config.Synonyms[name] {
+			for _, builtin := range defaultSynonyms[normalizeProductName(alias, nil)] {
+				c.canonical[normalizeProductName(builtin, nil)] = name
+			}
+		}
+	}
+	add(config.Synonyms)
+
+	for term := range c.canonical {
+		if utf8.RuneCountInString(term) >= 2 {
+			c.terms = append(c.terms, term)
+		}
+	}
+	sort.Slice(c.terms, func(i, j int) bool {
+		if len(c.terms[i]) != len(c.terms[j]) {
+			return len(c.terms[i]) > len(c.terms[j])
+		}
+		return c.terms[i] < c.terms[j]
+	})
+
+	for _, word := range config.NoiseWords {
+		if word = strings.TrimSpace(word); word != "" {
+			c.noiseWords = append(c.noiseWords, word)
+		}
+	}
+	for _, word := range append(defaultSuffixModifiers, config.SuffixModifiers...) {
+		if word = normalizeProductName(word, nil); word != "" {
+			c.modifiers = append(c.modifiers, word)
+		}
+	}
+	// 长的前缀优先，如 内蒙古 先于 内蒙
+	sort.SliceStable(c.modifiers, func(i, j int) bool { return len(c.modifiers[i]) > len(c.modifiers[j]) })
+	return c, nil
+}
+
+// catalogs 按词典配置缓存编译后的词典，键为配置的JSON
+var catalogs sync.Map
+
+// catalogFor 返回配置对应的词典，同一配置只编译一次，配置已在加载时校验过
+func catalogFor(config Config) *productCatalog {
+	key, _ := json.Marshal(config.Catalog)
+	if c, ok := catalogs.Load(string(key)); ok {
+		return c.(*productCatalog)
+	}
+	c, err := newProductCatalog(config.Catalog)
+	if err != nil {
+		c, _ = newProductCatalog(CatalogConfig{})
+	}
+	actual, _ := catalogs.LoadOrStore(string(key), c)
+	return actual.(*productCatalog)
+}
+
+// validateCatalog 检查名称词典配置
+func (c *Config) validateCatalog() error {
+	_, err := newProductCatalog(c.Catalog)
+	return err
+}
+
+// Normalize 去掉名称中的括号内容、重量和营销词
+func (c *productCatalog) Normalize(name string) string {
+	return normalizeProductName(name, c.noiseWords)
+}
+
+// Key 商品的规范名称：规范化后的名称在词典中时取对应的规范名称，
+// 否则名称为品种、产地前缀加词条时取最长的词条，如 “普罗旺斯番茄” -> 西红柿；
+// 都没有时为规范化后的名称
+func (c *productCatalog) Key(name string) string {
+	normalized := c.Normalize(name)
+	if normalized == "" {
+		return ""
+	}
+	if canonical, ok := c.canonical[normalized]; ok {
+		return canonical
+	}
+	for _, term := range c.terms {
+		if prefix, ok := strings.CutSuffix(normalized, term); ok && c.isModifier(prefix) {
+			return c.canonical[term]
+		}
+	}
+	return normalized
+}
+
+// isModifier 判断名称中词条前的部分是否全由允许的前缀组成，如 有机普罗旺斯
+func (c *productCatalog) isModifier(prefix string) bool {
+	if prefix == "" {
+		return false
+	}
+	for prefix != "" {
+		matched := false
+		for _, word := range c.modifiers {
+			if rest, ok := strings.CutPrefix(prefix, word); ok {
+				prefix, matched = rest, true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeProductName 名称规范化：全角转半角、去掉括号内容、重量片段、营销词和标点
+func normalizeProductName(name string, extraNoise []string) string {
+	name = normalizeSpec(name)
+	name = nameBracketPattern.ReplaceAllString(name, " ")
+	name = nameWeightPattern.ReplaceAllString(name, " ")
+	for _, words := range [][]string{defaultNoiseWords, extraNoise} {
+		for _, word := range words {
+			name = strings.ReplaceAll(name, word, " ")
+		}
+	}
+	return nameSeparatorPattern.ReplaceAllString(name, "")
+}
+
+// assignCanonicalKeys 为商品设置规范名称
+func assignCanonicalKeys(config Config, products []Product) {
+	catalog := catalogFor(config)
+	for i := range products {
+		products[i].CanonicalKey = catalog.Key(products[i].Name)
+	}
+}
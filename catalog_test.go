@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestProductCatalog 测试商品名称规范化和同物异名
+func TestProductCatalog(t *testing.T) {
+	catalog := catalogFor(Config{})
+	tests := []struct {
+		name       string
+		normalized string
+		key        string
+	}{
+		{"【精品】本地新鲜西红柿约500g", "西红柿", "西红柿"},
+		{"番茄", "番茄", "西红柿"},
+		{"普罗旺斯番茄 500g/盒", "普罗旺斯番茄", "西红柿"},
+		{"小番茄(250g)", "小番茄", "圣女果"}, // 别名完全匹配优先于后缀
+		{"有机小番茄", "有机小番茄", "圣女果"},   // 最长的后缀词条优先
+		{"马铃薯 2斤装", "马铃薯", "土豆"},
+		{"红萝卜", "红萝卜", "胡萝卜"},
+		{"白萝卜", "白萝卜", "白萝卜"},
+		{"蒜薹 约250g", "蒜薹", "蒜苔"},
+		{"杏鲍菇 250g*2", "杏鲍菇", "杏鲍菇"},
+		{"娃娃菜3颗装", "娃娃菜", "娃娃菜"},
+		{"一把香菜", "香菜", "香菜"},
+		{"芹菜（本地）", "芹菜", "芹菜"},
+		{"[特价]黄瓜/根", "黄瓜", "黄瓜"},
+		{"【特价】", "", ""},
+		// 以词条结尾的其他菜和只是包含词条的名称不归类
+		{"黄花菜", "黄花菜", "黄花菜"},
+		{"韭菜花", "韭菜花", "韭菜花"},
+		{"油菜花", "油菜花", "油菜花"},
+		{"洋姜", "洋姜", "洋姜"},
+		{"番茄酱", "番茄酱", "番茄酱"},
+		{"紫甘蓝", "紫甘蓝", "紫甘蓝"},
+		{"白芸豆", "白芸豆", "白芸豆"},
+		{"羽衣甘蓝", "羽衣甘蓝", "羽衣甘蓝"},
+		{"有机普罗旺斯番茄", "有机普罗旺斯番茄", "西红柿"}, // 多个前缀
+	}
+	for _, tc := range tests {
+		if got := catalog.Normalize(tc.name); got != tc.normalized {
+			t.Errorf("Normalize(%q) = %q, 期望 %q", tc.name, got, tc.normalized)
+		}
+		if got := catalog.Key(tc.name); got != tc.key {
+			t.Errorf("Key(%q) = %q, 期望 %q", tc.name, got, tc.key)
+		}
+	}
+
+	// 配置中的别名和营销词与内置词典合并，配置优先
+	custom, err := newProductCatalog(CatalogConfig{
+		Synonyms:        map[string][]string{"番茄": {"西红柿"}, "蒜苔": {"蒜苗"}},
+		NoiseWords:      []string{"山东"},
+		SuffixModifiers: []string{"紫"},
+	})
+	if err != nil {
+		t.Fatalf("newProductCatalog 返回错误: %v", err)
+	}
+	for name, key := range map[string]string{"西红柿": "番茄", "洋柿子": "番茄", "蒜苗": "蒜苔", "山东大葱": "大葱", "紫甘蓝": "包菜"} {
+		if got := custom.Key(name); got != key {
+			t.Errorf("自定义词典 Key(%q) = %q, 期望 %q", name, got, key)
+		}
+	}
+	if catalogFor(Config{}) != catalog {
+		t.Error("同一配置的词典应只编译一次")
+	}
+	if _, err := newProductCatalog(CatalogConfig{Synonyms: map[string][]string{"土豆": {"洋芋"}, "洋芋": nil}}); err == nil {
+		t.Error("同一别名属于两个规范名称时应返回错误")
+	}
+
+	// 历史记录和提醒规则按规范名称匹配改名后的商品
+	products := []Product{{ID: "p1", Name: "马铃薯 约500g", Price: 1.5, Spec: "500g"}}
+	products[0].updatePricePerJin()
+	assignCanonicalKeys(Config{}, products)
+	if products[0].CanonicalKey != "土豆" {
+		t.Fatalf("CanonicalKey = %q, 期望 土豆", products[0].CanonicalKey)
+	}
+	o := newObservations("root", "", products, time.Now())[0]
+	if !(HistoryQuery{Name: "土豆", Key: catalog.Key("土豆")}).match(o) || (HistoryQuery{Name: "土豆"}).match(o) {
+		t.Errorf("按规范名称查询历史失败: %+v", o)
+	}
+	rules := withProductKeys(catalog, []AlertRule{{ID: "potato", Product: "洋芋", Op: "<", Value: 2}})
+	categories := []Category{{ID: "root", Products: products, Status: CategoryStatusOK}}
+	if alerts := evaluateAlerts(rules, categories, nil, time.Now()); len(alerts) != 1 {
+		t.Errorf("按规范名称匹配提醒规则 = %+v, 期望1条", alerts)
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// PriceSource 参与比价的来源：配置了门店时为门店，否则为网站
+type PriceSource struct {
+	ID   string `json:"id"`   // 门店ID或网站适配器名称
+	Name string `json:"name"` // 门店名称或网站说明
+}
+
+// SourcePrice 一个商品在某个来源的价格，同一来源有多个商品时取每斤价格最低的
+type SourcePrice struct {
+	Source          string  `json:"source"`      // 来源ID
+	SourceName      string  `json:"source_name"` // 来源名称
+	CategoryID      string  `json:"category_id"`
+	ProductID       string  `json:"product_id"`
+	Name            string  `json:"name"` // 商品原名称
+	Spec            string  `json:"spec"`
+	Price           float64 `json:"price"`
+	PricePerJin     float64 `json:"price_per_jin"`
+	WeightEstimated bool    `json:"weight_estimated,omitempty"` // 每斤价格是否按估算重量计算
+}
+
+// PriceComparison 同一商品在各来源的价格对比
+type PriceComparison struct {
+	Key          string        `json:"key"`           // 规范名称
+	Unit         string        `json:"unit"`          // 价格单位，元/斤 或 元/盒 等包装单位
+	Prices       []SourcePrice `json:"prices"`        // 各来源的价格，按每斤价格从低到高
+	Cheapest     string        `json:"cheapest"`      // 最便宜的来源ID
+	CheapestName string        `json:"cheapest_name"` // 最便宜的来源名称
+	MinPerJin    float64       `json:"min_per_jin"`   // 最低每斤价格
+	MaxPerJin    float64       `json:"max_per_jin"`   // 最高每斤价格
+	Spread       float64       `json:"spread"`        // 最高与最低每斤价格之差
+	SpreadPct    float64       `json:"spread_pct"`    // 差价占最低价格的百分比
+}
+
+// PriceAt 商品在某个来源的价格，该来源没有时返回nil，用于页面按来源列出价格
+func (c PriceComparison) PriceAt(source string) *SourcePrice {
+	for i := range c.Prices {
+		if c.Prices[i].Source == source {
+			return &c.Prices[i]
+		}
+	}
+	return nil
+}
+
+// ComparisonReport 跨门店比价结果
+type ComparisonReport struct {
+	Sources []PriceSource     `json:"sources"` // 参与比价的来源，按页面中的顺序
+	Items   []PriceComparison `json:"items"`   // 至少两个来源都有的商品，按差价百分比从大到小
+}
+
+// priceSource 分类中商品的来源
+func priceSource(c Category) PriceSource {
+	if c.Store != "" {
+		return PriceSource{ID: c.Store, Name: c.StoreName}
+	}
+	name := c.Adapter
+	if adapter, err := getAdapter(c.Adapter); err == nil {
+		name = adapter.Description()
+	}
+	return PriceSource{ID: c.Adapter, Name: name}
+}
+
+// comparePrices 按规范名称和价格单位将页面中的商品分组，比较各来源的每斤价格。
+// 抓取失败的分类和无法计算价格的商品不参与比较，keyword 不为空时只比较规范名称或名称中包含关键词的商品
+func comparePrices(data PageData, keyword string) ComparisonReport {
+	report := ComparisonReport{Sources: make([]PriceSource, 0), Items: make([]PriceComparison, 0)}
+	seen := make(map[string]bool)
+	groups := make(map[string]*PriceComparison)
+	var order []string
+	for _, c := range data.Categories {
+		if c.Status == CategoryStatusFailed {
+			continue
+		}
+		source := priceSource(c)
+		if !seen[source.ID] {
+			seen[source.ID] = true
+			report.Sources = append(report.Sources, source)
+		}
+		for _, p := range c.Products {
+			if p.PricePerJin <= 0 || p.CanonicalKey == "" {
+				continue
+			}
+			if keyword != "" && !strings.Contains(p.CanonicalKey, keyword) && !strings.Contains(p.Name, keyword) {
+				continue
+			}
+			key := p.CanonicalKey + "|" + p.Unit
+			group, ok := groups[key]
+			if !ok {
+				group = &PriceComparison{Key: p.CanonicalKey, Unit: p.Unit}
+				groups[key] = group
+				order = append(order, key)
+			}
+			price := SourcePrice{
+				Source:          source.ID,
+				SourceName:      source.Name,
+				CategoryID:      c.ID,
+				ProductID:       p.ID,
+				Name:            p.Name,
+				Spec:            p.Spec,
+				Price:           p.Price,
+				PricePerJin:     p.PricePerJin,
+				WeightEstimated: p.WeightEstimated,
+			}
+			// 同一来源只保留最便宜的
+			if existing := group.PriceAt(source.ID); existing != nil {
+				if price.PricePerJin < existing.PricePerJin {
+					*existing = price
+				}
+				continue
+			}
+			group.Prices = append(group.Prices, price)
+		}
+	}
+
+	for _, key := range order {
+		group := groups[key]
+		if len(group.Prices) < 2 {
+			continue
+		}
+		sort.SliceStable(group.Prices, func(i, j int) bool { return group.Prices[i].PricePerJin < group.Prices[j].PricePerJin })
+		cheapest := group.Prices[0]
+		group.Cheapest = cheapest.Source
+		group.CheapestName = cheapest.SourceName
+		group.MinPerJin = cheapest.PricePerJin
+		group.MaxPerJin = group.Prices[len(group.Prices)-1].PricePerJin
+		group.Spread = math.Round((group.MaxPerJin-group.MinPerJin)*100) / 100
+		group.SpreadPct = math.Round(group.Spread/group.MinPerJin*1000) / 10
+		report.Items = append(report.Items, *group)
+	}
+	sort.SliceStable(report.Items, func(i, j int) bool {
+		if report.Items[i].SpreadPct != report.Items[j].SpreadPct {
+			return report.Items[i].SpreadPct > report.Items[j].SpreadPct
+		}
+		return report.Items[i].Key < report.Items[j].Key
+	})
+	return report
+}
+
+// runCompareMode 抓取多个门店并比较同一商品的价格
+// 用法: compare [-store all] [-unit kg] [-json] [关键词]
+func runCompareMode(args []string) {
+	flags := flag.NewFlagSet("compare", flag.ExitOnError)
+	storeFlag := flags.String("store", AllStores, "参与比价的门店ID，多个用逗号分隔，默认所有门店")
+	unitFlag := flags.String("unit", "", "价格单位：jin / kg / 500g / 100g，默认读取配置文件中的 price_unit")
+	asJSON := flags.Bool("json", false, "输出JSON")
+	flags.Parse(args)
+
+	config, err := LoadConfig("config.json")
+	if err != nil {
+		fmt.Printf("加载配置文件失败: %v\n", err)
+		return
+	}
+	unit, err := priceUnitFor(*config, *unitFlag)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	stores, err := config.selectStores(*storeFlag)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	data := fetchStoresConcurrently(context.Background(), *config, stores)
+	report := comparePrices(data, strings.Join(flags.Args(), ""))
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+		return
+	}
+	printComparison(report, unit)
+}
+
+// printComparison 以表格形式打印比价结果，每列一个来源，每斤价格按 unit 换算
+func printComparison(report ComparisonReport, unit PriceUnit) {
+	if len(report.Sources) < 2 {
+		fmt.Println("至少需要两个门店或网站的数据才能比价，请在配置文件中设置 stores")
+		return
+	}
+	if len(report.Items) == 0 {
+		fmt.Println("没有多个门店都有的商品")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, "商品")
+	for _, s := range report.Sources {
+		fmt.Fprintf(w, "\t%s", s.Name)
+	}
+	fmt.Fprint(w, "\t最便宜\t差价\n")
+	for _, item := range report.Items {
+		fmt.Fprint(w, item.Key)
+		for _, s := range report.Sources {
+			if p := item.PriceAt(s.ID); p != nil {
+				fmt.Fprintf(w, "\t%.2f", unit.Convert(p.PricePerJin, item.Unit))
+			} else {
+				fmt.Fprint(w, "\t-")
+			}
+		}
+		fmt.Fprintf(w, "\t%s\t%.2f%s（%.1f%%）\n", item.CheapestName, unit.Convert(item.Spread, item.Unit), unit.Display(item.Unit), item.SpreadPct)
+	}
+	w.Flush()
+}
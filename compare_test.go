@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestComparePrices 测试按规范名称分组比较各门店的每斤价格
+func TestComparePrices(t *testing.T) {
+	product := func(id, name, key string, price float64, unit string) Product {
+		return Product{ID: id, Name: name, CanonicalKey: key, Price: price, PricePerJin: price, Unit: unit}
+	}
+	data := PageData{Categories: []Category{
+		{ID: "fruit", Store: "012", StoreName: "文华路店", Status: CategoryStatusOK, Products: []Product{
+			product("1", "西红柿", "西红柿", 3.98, "元/斤"),
+			product("2", "【精品】番茄", "西红柿", 4.98, "元/斤"),
+			product("3", "土豆", "土豆", 1.50, "元/斤"),
+			product("4", "线椒（盒）", "线椒", 5.80, "元/盒"),
+		}},
+		{ID: "fruit", Store: "015", StoreName: "泽州路店", Status: CategoryStatusOK, Products: []Product{
+			product("1", "西红柿", "西红柿", 2.98, "元/斤"),
+			product("3", "土豆", "土豆", 1.80, "元/斤"),
+			product("4", "线椒", "线椒", 6.00, "元/斤"),
+			product("5", "香菜", "香菜", 0, "元/斤"),
+		}},
+		{ID: "leaf", Store: "015", StoreName: "泽州路店", Status: CategoryStatusFailed},
+		{ID: "fruit", Store: "020", StoreName: "凤台街店", Status: CategoryStatusFailed},
+	}}
+
+	report := comparePrices(data, "")
+	if len(report.Sources) != 2 || report.Sources[0].Name != "文华路店" || report.Sources[1].ID != "015" {
+		t.Errorf("Sources = %+v", report.Sources)
+	}
+	// 线椒单位不同不比较，香菜没有价格
+	var got []string
+	for _, item := range report.Items {
+		got = append(got, fmt.Sprintf("%s:%s:%.2f:%.1f", item.Key, item.CheapestName, item.Spread, item.SpreadPct))
+	}
+	want := "西红柿:泽州路店:1.00:33.6,土豆:文华路店:0.30:20.0"
+	if strings.Join(got, ",") != want {
+		t.Errorf("比价结果 = %v, 期望 %s", got, want)
+	}
+	if p := report.Items[0].PriceAt("012"); p == nil || p.Name != "西红柿" || p.PricePerJin != 3.98 {
+		t.Errorf("文华路店的西红柿 = %+v, 期望取最便宜的SKU", p)
+	}
+
+	if report := comparePrices(data, "土豆"); len(report.Items) != 1 || report.Items[0].Key != "土豆" {
+		t.Errorf("按关键词比价结果 = %+v", report.Items)
+	}
+
+	data.PriceUnit = defaultPriceUnit
+	data.Comparison = &report
+	out, err := renderProductList("", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareTitle := `<h2 class="category-title text-xl font-semibold text-gray-800">门店比价</h2>`
+	for _, want := range []string{compareTitle, `<td class="cheapest" title="西红柿 ">2.98</td>`, "1.00元/斤（33.6%）"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("页面中缺少 %q", want)
+		}
+	}
+
+	// 只选了一个门店时没有比价数据，比价标签链接到全部门店页面
+	data.Comparison = nil
+	data.Stores = []StoreConfig{{ID: "012", Name: "文华路店"}, {ID: "015", Name: "泽州路店"}}
+	if out, err = renderProductList("", data); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `href="?store=all&unit=jin#compare"`) || strings.Contains(out, compareTitle) {
+		t.Error("单门店页面应显示链接到全部门店比价的标签")
+	}
+}
@@ -4,20 +4,219 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 )
 
+// CategoryConfig 商品分类配置
+type CategoryConfig struct {
+	ID      string `json:"id"`                // 分类ID，用于页面标签和JSON输出
+	Name    string `json:"name"`              // 显示名称
+	URL     string `json:"url,omitempty"`     // 分类页面URL，为空时由网站适配器生成
+	Adapter string `json:"adapter,omitempty"` // 网站适配器，默认 fengzhansy
+	Order   int    `json:"order,omitempty"`   // 排序，数值小的在前，相同时按配置顺序
+	Enabled *bool  `json:"enabled,omitempty"` // 是否启用，默认启用
+	Store   string `json:"store,omitempty"`   // 只在该门店抓取；抓取多个门店时自动设置为当前门店
+}
+
+// IsEnabled 分类是否启用
+func (c CategoryConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// HistoryConfig 价格历史存储配置
+type HistoryConfig struct {
+	Driver string `json:"driver"` // 存储类型：none / jsonl / bolt
+	Path   string `json:"path"`   // 存储文件路径
+}
+
+// DetailConfig 商品详情页抓取配置
+type DetailConfig struct {
+	Enabled         bool `json:"enabled"`           // 是否抓取商品详情页，默认关闭
+	Concurrency     int  `json:"concurrency"`       // 同时抓取的详情页数量
+	CacheTTLSeconds int  `json:"cache_ttl_seconds"` // 详情缓存时间（秒），详情页变化较少，可比分类缓存长
+}
+
+// NotifierConfig 提醒通知渠道配置
+type NotifierConfig struct {
+	Type   string `json:"type"`             // 渠道类型：webhook / wecom / dingtalk / feishu / email
+	URL    string `json:"url,omitempty"`    // webhook或机器人地址
+	Secret string `json:"secret,omitempty"` // 钉钉、飞书机器人的加签密钥
+
+	SMTPHost string   `json:"smtp_host,omitempty"` // 邮件：SMTP服务器
+	SMTPPort int      `json:"smtp_port,omitempty"` // 邮件：SMTP端口，默认25，tls时默认465
+	Username string   `json:"username,omitempty"`  // 邮件：登录用户名
+	Password string   `json:"password,omitempty"`  // 邮件：登录密码或授权码
+	From     string   `json:"from,omitempty"`      // 邮件：发件人
+	To       []string `json:"to,omitempty"`        // 邮件：收件人
+	TLS      bool     `json:"tls,omitempty"`       // 邮件：是否直接使用TLS连接（465端口）
+}
+
+// AlertConfig 价格提醒配置
+type AlertConfig struct {
+	Rules      []AlertRule      `json:"rules"`       // 提醒规则
+	Notifiers  []NotifierConfig `json:"notifiers"`   // 通知渠道
+	DedupHours int              `json:"dedup_hours"` // 同一提醒的最短重复发送间隔（小时）
+	StatePath  string           `json:"state_path"`  // 已发送提醒记录文件
+}
+
+// validate 检查提醒配置
+func (a AlertConfig) validate() error {
+	seen := make(map[string]bool)
+	for _, rule := range a.Rules {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+		if seen[rule.ID] {
+			return fmt.Errorf("提醒规则id重复: %s", rule.ID)
+		}
+		seen[rule.ID] = true
+	}
+	for _, notifier := range a.Notifiers {
+		if err := validateNotifier(notifier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Config 配置结构体
 type Config struct {
-	UrlFv string `json:"url_fv"` // 瓜果花菜类
-	UrlLv string `json:"url_lv"` // 叶菜类
-	UrlRv string `json:"url_rv"` // 根茎类
-	UrlM  string `json:"url_m"`  // 菌菇类
-	UrlC  string `json:"url_c"`  // 调味菜
+	Categories []CategoryConfig `json:"categories"` // 商品分类列表
+
+	// 旧版配置项，加载时自动迁移到 Categories
+	URL   string `json:"url,omitempty"`    // 已废弃：单个URL，等同于 url_fv
+	UrlFv string `json:"url_fv,omitempty"` // 已废弃：瓜果花菜类
+	UrlLv string `json:"url_lv,omitempty"` // 已废弃：叶菜类
+	UrlRv string `json:"url_rv,omitempty"` // 已废弃：根茎类
+	UrlM  string `json:"url_m,omitempty"`  // 已废弃：菌菇类
+	UrlC  string `json:"url_c,omitempty"`  // 已废弃：调味菜
 
 	Cookie     string `json:"cookie"`      // 认证cookie
 	UserAgent  string `json:"user_agent"`  // 用户代理
 	Timeout    int    `json:"timeout"`     // 超时时间（秒）
 	RetryCount int    `json:"retry_count"` // 重试次数
+
+	RetryBaseDelayMs int `json:"retry_base_delay_ms"` // 重试基础等待时间（毫秒），按指数递增
+	RetryMaxDelayMs  int `json:"retry_max_delay_ms"`  // 单次重试等待时间上限（毫秒）
+
+	Stores []StoreConfig `json:"stores,omitempty"` // 门店列表，第一个为默认门店；为空时按 cookie 中的门店抓取
+
+	ListenAddr string `json:"listen_addr"` // serve 模式的监听地址
+
+	TemplateDir string `json:"template_dir,omitempty"` // 页面模板和静态文件目录，设置后每次请求从该目录读取，用于调试模板
+	StaticURL   string `json:"static_url"`             // 页面引用静态文件的URL前缀，为空时 serve 模式使用 /static/，其他情况内联CSS
+
+	CacheTTLSeconds   int `json:"cache_ttl_seconds"`   // 分类结果缓存时间（秒），小于0时关闭缓存
+	CacheStaleSeconds int `json:"cache_stale_seconds"` // 缓存过期后仍可先返回旧数据的时间（秒）
+
+	History HistoryConfig `json:"history"` // 价格历史存储
+
+	Selectors map[string]SelectorConfig `json:"selectors,omitempty"` // 按网站适配器覆盖页面解析规则
+
+	Details DetailConfig `json:"details"` // 商品详情页抓取
+
+	PriceUnit string `json:"price_unit"` // 重量商品价格的默认显示单位：jin / kg / 500g / 100g
+
+	Catalog CatalogConfig `json:"catalog"` // 商品名称规范化和同物异名词典，与内置词典合并
+
+	WeightEstimates []WeightEstimate `json:"weight_estimates,omitempty"` // 按件卖的商品每件的估算重量，优先于内置规则
+
+	Alerts AlertConfig `json:"alerts"` // 价格提醒
+
+	Watchlists WatchlistConfig `json:"watchlists"` // 服务端收藏夹，多台设备共用同一个收藏夹ID同步收藏
+}
+
+// defaultCategories 默认的凤展超市分类
+func defaultCategories() []CategoryConfig {
+	return []CategoryConfig{
+		{ID: "fruit-vegetable", Name: "瓜果花菜类", URL: "https://www.fengzhansy.com/wchyzyg/wap.shtml?method=ztmodel&ztid=gfl00%E7%93%9C%E6%9E%9C%E8%8A%B1%E8%8F%9C%E7%B1%BB"},
+		{ID: "leaf-vegetable", Name: "叶菜类", URL: "https://www.fengzhansy.com/wchyzyg/wap.shtml?method=ztmodel&ztid=gfl00%E5%8F%B6%E8%8F%9C%E7%B1%BB"},
+		{ID: "root-vegetable", Name: "根茎类", URL: "https://www.fengzhansy.com/wchyzyg/wap.shtml?method=ztmodel&ztid=gfl00%E6%A0%B9%E8%8C%8E%E7%B1%BB"},
+		{ID: "mushroom", Name: "菌菇类", URL: "https://www.fengzhansy.com/wchyzyg/wap.shtml?method=ztmodel&ztid=gfl00%E8%8F%8C%E8%8F%87%E7%B1%BB"},
+		{ID: "condiment", Name: "调味菜", URL: "https://www.fengzhansy.com/wchyzyg/wap.shtml?method=ztmodel&ztid=gfl00%E8%B0%83%E5%91%B3%E8%8F%9C"},
+	}
+}
+
+// migrateLegacyURLs 将旧版 url / url_* 配置项转换为分类列表
+func (c *Config) migrateLegacyURLs() {
+	if len(c.Categories) > 0 {
+		return
+	}
+
+	fv := c.UrlFv
+	if fv == "" {
+		fv = c.URL
+	}
+	legacy := []CategoryConfig{
+		{ID: "fruit-vegetable", Name: "瓜果花菜类", URL: fv},
+		{ID: "leaf-vegetable", Name: "叶菜类", URL: c.UrlLv},
+		{ID: "root-vegetable", Name: "根茎类", URL: c.UrlRv},
+		{ID: "mushroom", Name: "菌菇类", URL: c.UrlM},
+		{ID: "condiment", Name: "调味菜", URL: c.UrlC},
+	}
+	for _, category := range legacy {
+		if category.URL != "" {
+			c.Categories = append(c.Categories, category)
+		}
+	}
+	if len(c.Categories) > 0 {
+		fmt.Println("提示: url / url_* 配置项已废弃，已自动转换为 categories，建议更新配置文件")
+	}
+}
+
+// validateCategories 检查分类配置
+func (c *Config) validateCategories() error {
+	seen := make(map[string]bool)
+	enabled := 0
+	for i, category := range c.Categories {
+		if category.ID == "" {
+			return fmt.Errorf("第%d个分类缺少id", i+1)
+		}
+		if seen[category.ID] {
+			return fmt.Errorf("分类id重复: %s", category.ID)
+		}
+		seen[category.ID] = true
+		if category.Name == "" {
+			return fmt.Errorf("分类 %s 缺少name", category.ID)
+		}
+		if category.IsEnabled() {
+			adapter, err := getAdapter(category.Adapter)
+			if err != nil {
+				return fmt.Errorf("分类 %s: %v", category.ID, err)
+			}
+			if _, err := adapter.CategoryURL(category); err != nil {
+				return err
+			}
+			enabled++
+		}
+	}
+	if enabled == 0 {
+		return fmt.Errorf("配置文件中缺少URL，至少需要一个启用的分类")
+	}
+	return nil
+}
+
+// EnabledCategories 返回启用的分类，按 order 排序
+func (c *Config) EnabledCategories() []CategoryConfig {
+	categories := make([]CategoryConfig, 0, len(c.Categories))
+	for _, category := range c.Categories {
+		if category.IsEnabled() {
+			categories = append(categories, category)
+		}
+	}
+	sort.SliceStable(categories, func(i, j int) bool {
+		return categories[i].Order < categories[j].Order
+	})
+	return categories
+}
+
+// DefaultCategory 返回第一个启用的分类，用于只抓取单个分类的模式
+func (c *Config) DefaultCategory() CategoryConfig {
+	categories := c.EnabledCategories()
+	if len(categories) == 0 {
+		return CategoryConfig{}
+	}
+	return categories[0]
 }
 
 // LoadConfig 从配置文件加载配置
@@ -34,13 +233,37 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("解析配置文件失败: %v", err)
 	}
 
+	// 兼容旧版配置
+	config.migrateLegacyURLs()
+
 	// 验证必要的配置项
-	if config.UrlFv == "" {
-		return nil, fmt.Errorf("配置文件中缺少URL")
+	if err := config.validateCategories(); err != nil {
+		return nil, err
 	}
 	if config.Cookie == "" {
 		return nil, fmt.Errorf("配置文件中缺少Cookie")
 	}
+	if err := config.validateStores(); err != nil {
+		return nil, err
+	}
+	if err := config.validateSelectors(); err != nil {
+		return nil, err
+	}
+	if _, err := parsePriceUnit(config.PriceUnit); err != nil {
+		return nil, fmt.Errorf("price_unit: %v", err)
+	}
+	if err := config.validateCatalog(); err != nil {
+		return nil, err
+	}
+	if err := config.validateWeightEstimates(); err != nil {
+		return nil, err
+	}
+	if err := config.Alerts.validate(); err != nil {
+		return nil, err
+	}
+	if err := config.validateWatchlists(); err != nil {
+		return nil, err
+	}
 
 	// 设置默认值
 	if config.UserAgent == "" {
@@ -52,6 +275,39 @@ func LoadConfig(configPath string) (*Config, error) {
 	if config.RetryCount <= 0 {
 		config.RetryCount = 3
 	}
+	if config.RetryBaseDelayMs <= 0 {
+		config.RetryBaseDelayMs = 500
+	}
+	if config.RetryMaxDelayMs <= 0 {
+		config.RetryMaxDelayMs = 8000
+	}
+	if config.ListenAddr == "" {
+		config.ListenAddr = ":8080"
+	}
+	if config.CacheTTLSeconds == 0 {
+		config.CacheTTLSeconds = 300
+	}
+	if config.CacheStaleSeconds <= 0 {
+		config.CacheStaleSeconds = 3600
+	}
+	if config.History.Driver == "" {
+		config.History.Driver = HistoryDriverNone
+	}
+	if config.Details.Concurrency <= 0 {
+		config.Details.Concurrency = 4
+	}
+	if config.Details.CacheTTLSeconds <= 0 {
+		config.Details.CacheTTLSeconds = 21600
+	}
+	if config.Alerts.DedupHours <= 0 {
+		config.Alerts.DedupHours = 24
+	}
+	if config.Alerts.StatePath == "" {
+		config.Alerts.StatePath = "alert_state.json"
+	}
+	if config.Watchlists.Path == "" {
+		config.Watchlists.Path = "watchlists.json"
+	}
 
 	return &config, nil
 }
@@ -62,15 +318,19 @@ func GetConfig() *Config {
 	if err != nil {
 		// 如果配置文件不存在，返回默认配置
 		return &Config{
-			UrlFv:      "https://www.fengzhansy.com/wchyzyg/wap.shtml?method=ztmodel&ztid=gfl00%E7%93%9C%E6%9E%9C%E8%8A%B1%E8%8F%9C%E7%B1%BB",
-			UrlLv:      "https://www.fengzhansy.com/wchyzyg/wap.shtml?method=ztmodel&ztid=gfl00%E5%8F%B6%E8%8F%9C%E7%B1%BB",
-			UrlRv:      "https://www.fengzhansy.com/wchyzyg/wap.shtml?method=ztmodel&ztid=gfl00%E6%A0%B9%E8%8C%8E%E7%B1%BB",
-			UrlM:       "https://www.fengzhansy.com/wchyzyg/wap.shtml?method=ztmodel&ztid=gfl00%E8%8F%8C%E8%8F%87%E7%B1%BB",
-			UrlC:       "https://www.fengzhansy.com/wchyzyg/wap.shtml?method=ztmodel&ztid=gfl00%E8%B0%83%E5%91%B3%E8%8F%9C",
+			Categories: defaultCategories(),
 			Cookie:     "shdzarea=%E6%96%87%E5%8D%8E%E8%B7%AF; scsmdid=012; shdzmdname=%E5%87%A4%E5%B1%95%E8%B6%85%E5%B8%82%E6%96%87%E5%8D%8E%E8%B7%AF%E5%BA%97",
 			UserAgent:  "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36",
 			Timeout:    30,
 			RetryCount: 3,
+
+			RetryBaseDelayMs: 500,
+			RetryMaxDelayMs:  8000,
+
+			ListenAddr: ":8080",
+
+			CacheTTLSeconds:   300,
+			CacheStaleSeconds: 3600,
 		}
 	}
 	return config
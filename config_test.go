@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLoadConfigCategories 测试分类配置的排序、禁用和旧版配置迁移
+func TestLoadConfigCategories(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// 新版配置：按order排序，跳过禁用的分类
+	config, err := LoadConfig(write("new.json", `{
+		"cookie": "a=b",
+		"categories": [
+			{"id": "fruit", "name": "水果类", "url": "https://example.com/fruit", "order": 2},
+			{"id": "meat", "name": "肉禽类", "url": "https://example.com/meat", "enabled": false},
+			{"id": "leaf", "name": "叶菜类", "url": "https://example.com/leaf", "order": 1}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("LoadConfig 返回错误: %v", err)
+	}
+	var ids []string
+	for _, c := range config.EnabledCategories() {
+		ids = append(ids, c.ID)
+	}
+	if strings.Join(ids, ",") != "leaf,fruit" {
+		t.Errorf("EnabledCategories = %v, 期望 [leaf fruit]", ids)
+	}
+
+	// 旧版配置：url_* 自动迁移
+	config, err = LoadConfig(write("legacy.json", `{"cookie": "a=b", "url_fv": "https://example.com/fv", "url_m": "https://example.com/m"}`))
+	if err != nil {
+		t.Fatalf("LoadConfig 返回错误: %v", err)
+	}
+	if len(config.Categories) != 2 || config.Categories[0].ID != "fruit-vegetable" || config.Categories[1].ID != "mushroom" {
+		t.Errorf("旧版配置迁移结果 = %+v", config.Categories)
+	}
+
+	// 非法配置
+	invalid := []string{
+		`{"cookie": "a=b"}`,
+		`{"cookie": "a=b", "categories": [{"id": "a", "name": "A", "url": "u"}, {"id": "a", "name": "B", "url": "u"}]}`,
+		`{"cookie": "a=b", "categories": [{"id": "a", "name": "A", "adapter": "unknown"}]}`,
+		`{"cookie": "a=b", "categories": [{"id": "a", "name": "A", "url": "u"}], "weight_estimates": [{"keywords": ["西瓜"], "jin": 0}]}`,
+		`{"cookie": "a=b", "categories": [{"id": "a", "name": "A", "url": "u"}], "weight_estimates": [{"units": ["个"], "jin": 8}]}`,
+		`{"cookie": "a=b", "categories": [{"id": "a", "name": "A", "url": "u"}], "price_unit": "两"}`,
+		`{"cookie": "a=b", "categories": [{"id": "a", "name": "A", "url": "u"}], "catalog": {"synonyms": {"西红柿": ["【特价】"]}}}`,
+		`{"cookie": "a=b", "categories": [{"id": "a", "name": "A", "url": "u"}], "stores": [{"id": "012", "name": "A"}, {"id": "012", "name": "B"}]}`,
+		`{"cookie": "a=b", "categories": [{"id": "a", "name": "A", "url": "u"}], "stores": [{"id": "all", "name": "A"}]}`,
+		`{"cookie": "a=b", "categories": [{"id": "a", "name": "A", "url": "u", "store": "015"}], "stores": [{"id": "012", "name": "A"}]}`,
+		`{"cookie": "a=b", "categories": [{"id": "a", "name": "A", "url": "u"}], "watchlists": {"ids": ["home 1"]}}`,
+		`{"cookie": "a=b", "categories": [{"id": "a", "name": "A", "url": "u"}], "watchlists": {"ids": ["home1"]}, "alerts": {"rules": [{"id": "r", "op": "<", "watchlist": "home2"}]}}`,
+	}
+	for i, content := range invalid {
+		if _, err := LoadConfig(write(fmt.Sprintf("invalid%d.json", i), content)); err == nil {
+			t.Errorf("LoadConfig(%s) 应返回错误", content)
+		}
+	}
+}
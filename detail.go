@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 库存状态
+const (
+	StockInStock    = "in_stock"     // 有货
+	StockOutOfStock = "out_of_stock" // 缺货或已售完
+)
+
+// ProductDetail 商品详情页中的补充信息
+type ProductDetail struct {
+	Images        []string
+	Origin        string
+	Description   string
+	Price         float64 // 详情页显示的当前售价，为0时保留列表页的价格
+	OriginalPrice float64
+	MemberPrice   float64
+	Stock         string
+	StockQuantity int
+}
+
+// detailAdapter 可选接口，支持抓取商品详情页的适配器实现
+type detailAdapter interface {
+	// DetailURL 返回商品详情页地址，pageURL 为商品所在的分类页面地址
+	DetailURL(pageURL string, p Product) (string, error)
+	// ParseDetail 从详情页解析补充信息，detailURL 用于补全相对地址
+	ParseDetail(ctx context.Context, config Config, detailURL, body string) (ProductDetail, error)
+}
+
+// productDetails 进程内的详情缓存，按详情页地址缓存，多个分类中的同一商品共用
+var productDetails = newDetailCache()
+
+// detailCache 商品详情缓存，只缓存成功的结果
+type detailCache struct {
+	mu       sync.Mutex
+	entries  map[string]detailEntry
+	purgedAt time.Time // 上次清理过期记录的时间
+}
+
+type detailEntry struct {
+	detail    ProductDetail
+	fetchedAt time.Time
+}
+
+func newDetailCache() *detailCache {
+	return &detailCache{entries: make(map[string]detailEntry)}
+}
+
+// Get 返回未过期的详情
+func (dc *detailCache) Get(url string, ttl time.Duration) (ProductDetail, bool) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	entry, ok := dc.entries[url]
+	if !ok || time.Since(entry.fetchedAt) >= ttl {
+		return ProductDetail{}, false
+	}
+	return entry.detail, true
+}
+
+// Put 保存详情。距上次清理超过 ttl 时顺带清理过期的记录，避免下架商品一直占用内存，
+// 每次保存不必遍历整个缓存
+func (dc *detailCache) Put(url string, detail ProductDetail, ttl time.Duration) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	now := time.Now()
+	if now.Sub(dc.purgedAt) >= ttl {
+		for key, entry := range dc.entries {
+			if now.Sub(entry.fetchedAt) >= ttl {
+				delete(dc.entries, key)
+			}
+		}
+		dc.purgedAt = now
+	}
+	dc.entries[url] = detailEntry{detail: detail, fetchedAt: now}
+}
+
+// fetchProductDetails 抓取商品详情页并补充到商品中，同时进行的请求不超过 details.concurrency。
+// 单个详情页失败时只跳过该商品，失败数量和第一个错误汇总打印；调用方取消时不再抓取剩余商品。
+// 详情抓取失败不影响分类结果
+func fetchProductDetails(ctx context.Context, config Config, adapter SiteAdapter, pageURL string, products []Product) {
+	da, ok := adapter.(detailAdapter)
+	if !ok || !config.Details.Enabled {
+		return
+	}
+	ttl := time.Duration(config.Details.CacheTTLSeconds) * time.Second
+	concurrency := config.Details.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		failed   int
+		firstErr error
+	)
+	sem := make(chan struct{}, concurrency)
+	for i := range products {
+		detailURL, err := da.DetailURL(pageURL, products[i])
+		if err != nil || detailURL == "" {
+			continue
+		}
+		// 库存等信息按门店不同，不同门店的详情分开缓存
+		cacheKey := detailURL
+		if products[i].Store != "" {
+			cacheKey += "|" + products[i].Store
+		}
+		if detail, ok := productDetails.Get(cacheKey, ttl); ok {
+			applyDetail(&products[i], detailURL, detail)
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(p *Product, detailURL, cacheKey string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			detail, err := fetchProductDetail(ctx, config, adapter, da, detailURL)
+			if err != nil {
+				mu.Lock()
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				return
+			}
+			productDetails.Put(cacheKey, detail, ttl)
+			applyDetail(p, detailURL, detail)
+		}(&products[i], detailURL, cacheKey)
+	}
+	wg.Wait()
+
+	if failed > 0 {
+		fmt.Printf("%d 个商品详情获取失败，第一个错误: %v\n", failed, firstErr)
+	}
+}
+
+// fetchProductDetail 获取并解析单个详情页
+func fetchProductDetail(ctx context.Context, config Config, adapter SiteAdapter, da detailAdapter, detailURL string) (ProductDetail, error) {
+	page, err := adapter.Fetch(ctx, config, detailURL)
+	if err != nil {
+		return ProductDetail{}, err
+	}
+	detail, err := da.ParseDetail(ctx, config, detailURL, page.Body)
+	if err != nil {
+		return ProductDetail{}, fmt.Errorf("解析详情页 %s 失败: %v", detailURL, err)
+	}
+	return detail, nil
+}
+
+// applyDetail 将详情补充到商品中，详情页有价格时以详情页为准并重新计算每斤价格
+func applyDetail(p *Product, detailURL string, d ProductDetail) {
+	p.DetailURL = detailURL
+	p.Images = d.Images
+	p.Origin = d.Origin
+	p.Description = d.Description
+	p.Stock = d.Stock
+	p.StockQuantity = d.StockQuantity
+	if d.Price > 0 {
+		p.Price = d.Price
+	}
+	if d.OriginalPrice > 0 {
+		p.OriginalPrice = d.OriginalPrice
+	}
+	if d.MemberPrice > 0 {
+		p.MemberPrice = d.MemberPrice
+	}
+	p.updatePricePerJin()
+}
+
+// detailFromFields 由详情页提取到的字段生成详情，pageURL 用于补全图片的相对地址
+func detailFromFields(fields map[string][]string, pageURL string) ProductDetail {
+	first := func(field string) string {
+		if values := fields[field]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	detail := ProductDetail{
+		Origin:        first(DetailOrigin),
+		Description:   strings.Join(strings.Fields(first(DetailDescription)), " "),
+		Price:         parseDetailPrice(first(DetailPrice)),
+		OriginalPrice: parseDetailPrice(first(DetailOriginalPrice)),
+		MemberPrice:   parseDetailPrice(first(DetailMemberPrice)),
+	}
+	detail.Stock, detail.StockQuantity = parseStock(first(DetailStock))
+
+	seen := make(map[string]bool)
+	for _, src := range fields[DetailImages] {
+		image, err := resolveURL(pageURL, src)
+		if err != nil || seen[image] {
+			continue
+		}
+		seen[image] = true
+		detail.Images = append(detail.Images, image)
+	}
+	return detail
+}
+
+// parseDetailPrice 解析价格文本中的售价，没有价格时返回0
+func parseDetailPrice(text string) float64 {
+	return parsePriceText(text).Price
+}
+
+// stockQuantityPattern 库存数量，如 库存：12、剩余3件
+var stockQuantityPattern = regexp.MustCompile(`(?:库存|剩余|仅剩)\s*[:：]?\s*(\d+)`)
+
+// parseStock 解析库存文本，返回库存状态和数量，无法判断时状态为空
+func parseStock(text string) (string, int) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", 0
+	}
+	for _, word := range []string{"缺货", "售罄", "售完", "无货", "已抢光", "暂无库存"} {
+		if strings.Contains(text, word) {
+			return StockOutOfStock, 0
+		}
+	}
+	if m := stockQuantityPattern.FindStringSubmatch(text); m != nil {
+		quantity, _ := strconv.Atoi(m[1])
+		if quantity == 0 {
+			return StockOutOfStock, 0
+		}
+		return StockInStock, quantity
+	}
+	for _, word := range []string{"有货", "现货", "充足"} {
+		if strings.Contains(text, word) {
+			return StockInStock, 0
+		}
+	}
+	return "", 0
+}
+
+// resolveURL 将页面中的相对地址补全为绝对地址，只接受 http / https
+func resolveURL(pageURL, ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") {
+		return "", fmt.Errorf("地址为空")
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	u = base.ResolveReference(u)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("不支持的地址 %s", ref)
+	}
+	return u.String(), nil
+}
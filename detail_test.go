@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestProductDetails 测试详情页抓取：字段解析、并发上限、缓存和单个详情页失败
+func TestProductDetails(t *testing.T) {
+	var inFlight, maxInFlight, detailRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/list":
+			fmt.Fprint(w, `<div class="index_picAD">
+<div><a href="detail?id=1"></a><h3>菠菜</h3><span class="price">￥3.98</span><span class="spec">500g</span></div>
+<div><a href="detail?id=2"></a><h3>土豆</h3><span class="price">￥2.00</span><span class="spec">1斤</span></div>
+<div><a href="detail?id=3"></a><h3>蒜苔</h3><span class="price">￥5.00</span><span class="spec">500g</span></div>
+<div><a href="/gone"></a><h3>香菜</h3><span class="price">￥1.00</span><span class="spec">100g</span></div>
+<div><a href="javascript:void(0)"></a><h3>生姜</h3><span class="price">￥6.00</span><span class="spec">500g</span></div>
+</div>`)
+		case "/detail":
+			atomic.AddInt32(&detailRequests, 1)
+			n := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+
+			switch r.URL.Query().Get("id") {
+			case "1":
+				fmt.Fprint(w, `<html><body>
+<div class="swiper-wrapper"><div class="swiper-slide"><img src="/upload/1a.jpg"></div><div class="swiper-slide"><img src="/upload/1b.jpg"></div><div class="swiper-slide"><img src="/upload/1a.jpg"></div></div>
+<div class="goods_info"><span class="now_price">￥3.58</span><del>￥4.98</del><span class="vip_price">会员价￥3.28</span></div>
+<p>产地：山东寿光</p>
+<p class="stock">库存：12</p>
+<div class="goods_desc">
+  新鲜菠菜，
+  当日到货
+</div>
+</body></html>`)
+			case "2":
+				fmt.Fprint(w, `<html><body><span class="now_price">￥2.00</span><p>原价 ￥1.80 会员价：1.90</p><div class="goods_origin">产地：内蒙古</div><p>已售完</p></body></html>`)
+			default:
+				fmt.Fprint(w, `<html><body><span class="now_price">￥5.00</span></body></html>`)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	config := Config{
+		Cookie:     "a=b",
+		Timeout:    5,
+		RetryCount: 0,
+		Details:    DetailConfig{Enabled: true, Concurrency: 2, CacheTTLSeconds: 60},
+	}
+	category := CategoryConfig{ID: "leaf", Name: "叶菜类", URL: server.URL + "/list"}
+	productDetails = newDetailCache()
+	defer func() { productDetails = newDetailCache() }()
+
+	products, err := fetchCategoryProducts(context.Background(), config, category)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 5 {
+		t.Fatalf("商品数量 = %d, 期望 5", len(products))
+	}
+	if got := atomic.LoadInt32(&maxInFlight); got > 2 {
+		t.Errorf("同时抓取的详情页 = %d, 期望不超过 2", got)
+	}
+
+	spinach := products[0]
+	wantImages := []string{server.URL + "/upload/1a.jpg", server.URL + "/upload/1b.jpg"}
+	if spinach.DetailURL != server.URL+"/detail?id=1" || fmt.Sprint(spinach.Images) != fmt.Sprint(wantImages) {
+		t.Errorf("菠菜 detail_url=%q images=%v", spinach.DetailURL, spinach.Images)
+	}
+	if spinach.Origin != "山东寿光" || spinach.Description != "新鲜菠菜， 当日到货" {
+		t.Errorf("菠菜 origin=%q description=%q", spinach.Origin, spinach.Description)
+	}
+	// 详情页的促销价覆盖列表价格，并重新计算每斤价格
+	if spinach.Price != 3.58 || spinach.PricePerJin != 3.58 || spinach.OriginalPrice != 4.98 || spinach.MemberPrice != 3.28 {
+		t.Errorf("菠菜 price=%v per_jin=%v original=%v member=%v", spinach.Price, spinach.PricePerJin, spinach.OriginalPrice, spinach.MemberPrice)
+	}
+	if spinach.Stock != StockInStock || spinach.StockQuantity != 12 {
+		t.Errorf("菠菜 stock=%q quantity=%d", spinach.Stock, spinach.StockQuantity)
+	}
+
+	// 原价不高于售价时不显示
+	potato := products[1]
+	if potato.Origin != "内蒙古" || potato.OriginalPrice != 0 || potato.MemberPrice != 1.90 || potato.Stock != StockOutOfStock {
+		t.Errorf("土豆 = %+v", potato)
+	}
+
+	// 详情页不存在或链接不是网页时保留列表信息
+	for _, p := range products[3:] {
+		if p.DetailURL != "" || p.Price == 0 {
+			t.Errorf("%s 不应有详情: %+v", p.Name, p)
+		}
+	}
+
+	// 再次抓取使用详情缓存
+	requests := atomic.LoadInt32(&detailRequests)
+	if _, err := fetchCategoryProducts(context.Background(), config, category); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&detailRequests); got != requests {
+		t.Errorf("缓存有效期内又请求了 %d 次详情页", got-requests)
+	}
+
+	// 页面中显示详情信息
+	out, err := renderProductList("", PageData{
+		Categories: []Category{{ID: "leaf", Name: "叶菜类", Products: products, Status: CategoryStatusOK, FetchedAt: time.Now()}},
+		Errors:     []CategoryError{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`src="` + server.URL + `/upload/1a.jpg"`, "<del>原价 4.98元（4.98元/斤）</del>", "每斤省 1.40元", "会员价 3.28元（3.28元/斤）", "产地: 山东寿光", "库存 12", `<span class="stock-tag">缺货</span>`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("页面中缺少 %q", want)
+		}
+	}
+
+	// 未开启时不请求详情页
+	config.Details.Enabled = false
+	productDetails = newDetailCache()
+	products, err = fetchCategoryProducts(context.Background(), config, category)
+	if err != nil || products[0].DetailURL != "" || atomic.LoadInt32(&detailRequests) != requests {
+		t.Errorf("未开启详情抓取时 products[0]=%+v err=%v", products[0], err)
+	}
+}
+
+// TestParseStock 测试库存文本解析
+func TestParseStock(t *testing.T) {
+	tests := []struct {
+		text     string
+		stock    string
+		quantity int
+	}{
+		{"库存：12", StockInStock, 12},
+		{"仅剩3件", StockInStock, 3},
+		{"库存 0", StockOutOfStock, 0},
+		{"已售完", StockOutOfStock, 0},
+		{"暂时缺货", StockOutOfStock, 0},
+		{"有货", StockInStock, 0},
+		{"", "", 0},
+		{"限购2份", "", 0},
+	}
+	for _, tc := range tests {
+		stock, quantity := parseStock(tc.text)
+		if stock != tc.stock || quantity != tc.quantity {
+			t.Errorf("parseStock(%q) = %q, %d, 期望 %q, %d", tc.text, stock, quantity, tc.stock, tc.quantity)
+		}
+	}
+}
+
+// TestDetailCachePurge 测试详情缓存距上次清理超过有效期时才清理过期记录
+func TestDetailCachePurge(t *testing.T) {
+	dc := newDetailCache()
+	ttl := time.Hour
+	dc.Put("a", ProductDetail{Origin: "山东"}, ttl)
+	dc.Put("b", ProductDetail{Origin: "云南"}, ttl)
+
+	// 刚清理过时保存不遍历缓存，过期的 a 仍在缓存中但读取不到
+	dc.entries["a"] = detailEntry{fetchedAt: time.Now().Add(-2 * ttl)}
+	dc.Put("c", ProductDetail{}, ttl)
+	if _, ok := dc.entries["a"]; !ok {
+		t.Error("距上次清理不到有效期时不应清理")
+	}
+	if _, ok := dc.Get("a", ttl); ok {
+		t.Error("过期的详情不应返回")
+	}
+
+	// 距上次清理超过有效期后，下一次保存清理过期记录
+	dc.purgedAt = time.Now().Add(-ttl)
+	dc.Put("d", ProductDetail{}, ttl)
+	if _, ok := dc.entries["a"]; ok || len(dc.entries) != 3 {
+		t.Errorf("清理后的缓存 = %v, 期望 b, c, d", dc.entries)
+	}
+	if detail, ok := dc.Get("b", ttl); !ok || detail.Origin != "云南" {
+		t.Errorf("未过期的详情 = %+v, %v", detail, ok)
+	}
+}
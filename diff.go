@@ -0,0 +1,387 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// chinaTime 按北京时间划分“今日”，不依赖运行环境的时区设置
+var chinaTime = time.FixedZone("CST", 8*3600)
+
+// startOfDay 返回北京时间当天零点
+func startOfDay(t time.Time) time.Time {
+	t = t.In(chinaTime)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, chinaTime)
+}
+
+// ProductChange 单个商品的变化
+type ProductChange struct {
+	CategoryID   string  `json:"category_id"`
+	Store        string  `json:"store,omitempty"`      // 门店ID
+	StoreName    string  `json:"store_name,omitempty"` // 门店名称
+	ProductID    string  `json:"product_id"`
+	Name         string  `json:"name"`
+	CanonicalKey string  `json:"canonical_key,omitempty"` // 规范名称
+	OldPrice     float64 `json:"old_price,omitempty"`     // 原价格
+	NewPrice     float64 `json:"new_price,omitempty"`     // 现价格
+	OldPerJin    float64 `json:"old_per_jin,omitempty"`   // 原每斤价格
+	NewPerJin    float64 `json:"new_per_jin,omitempty"`   // 现每斤价格
+	Change       float64 `json:"change,omitempty"`        // 价格变化（元）
+	ChangePct    float64 `json:"change_pct,omitempty"`    // 价格变化百分比
+	OldSpec      string  `json:"old_spec,omitempty"`      // 原规格
+	NewSpec      string  `json:"new_spec,omitempty"`      // 现规格
+	Unit         string  `json:"unit"`                    // 价格单位
+}
+
+// PriceDiff 两次抓取之间的变化
+type PriceDiff struct {
+	BaselineAt  time.Time       `json:"baseline_at"`  // 对比基准时间
+	ComparedAt  time.Time       `json:"compared_at"`  // 本次抓取时间
+	New         []ProductChange `json:"new"`          // 新上架
+	Removed     []ProductChange `json:"removed"`      // 已下架
+	Increased   []ProductChange `json:"increased"`    // 涨价
+	Decreased   []ProductChange `json:"decreased"`    // 降价
+	SpecChanged []ProductChange `json:"spec_changed"` // 规格变化
+}
+
+// HasChanges 是否有任何变化
+func (d *PriceDiff) HasChanges() bool {
+	return len(d.New)+len(d.Removed)+len(d.Increased)+len(d.Decreased)+len(d.SpecChanged) > 0
+}
+
+// comparablePrice 用于比较的价格：单位相同且都能算出每斤价格时比较每斤价格，否则比较标价
+func comparablePrice(prev, curr PriceObservation) (float64, float64) {
+	if prev.Unit == curr.Unit && prev.PricePerJin > 0 && curr.PricePerJin > 0 {
+		return prev.PricePerJin, curr.PricePerJin
+	}
+	return prev.Price, curr.Price
+}
+
+// diffSnapshots 比较基准快照与当前快照，只比较 categories 中的分类（抓取失败的分类不参与比较），
+// categories 的键为 historyScope，即分类ID和门店
+func diffSnapshots(baseline, current map[string]PriceObservation, categories map[string]bool) *PriceDiff {
+	diff := &PriceDiff{
+		New:         make([]ProductChange, 0),
+		Removed:     make([]ProductChange, 0),
+		Increased:   make([]ProductChange, 0),
+		Decreased:   make([]ProductChange, 0),
+		SpecChanged: make([]ProductChange, 0),
+	}
+
+	for key, curr := range current {
+		if !categories[historyScope(curr.CategoryID, curr.Store)] {
+			continue
+		}
+		change := ProductChange{
+			CategoryID:   curr.CategoryID,
+			Store:        curr.Store,
+			ProductID:    curr.ProductID,
+			Name:         curr.Name,
+			CanonicalKey: curr.CanonicalKey,
+			NewPrice:     curr.Price,
+			NewPerJin:    curr.PricePerJin,
+			NewSpec:      curr.Spec,
+			Unit:         curr.Unit,
+		}
+
+		prev, ok := baseline[key]
+		if !ok {
+			diff.New = append(diff.New, change)
+			continue
+		}
+		change.OldPrice = prev.Price
+		change.OldPerJin = prev.PricePerJin
+		change.OldSpec = prev.Spec
+
+		if prev.Spec != curr.Spec {
+			diff.SpecChanged = append(diff.SpecChanged, change)
+		}
+
+		oldPrice, newPrice := comparablePrice(prev, curr)
+		if oldPrice == newPrice {
+			continue
+		}
+		change.Change = math.Round((newPrice-oldPrice)*100) / 100
+		if oldPrice > 0 {
+			change.ChangePct = math.Round((newPrice-oldPrice)/oldPrice*1000) / 10
+		}
+		if newPrice > oldPrice {
+			diff.Increased = append(diff.Increased, change)
+		} else {
+			diff.Decreased = append(diff.Decreased, change)
+		}
+	}
+
+	for key, prev := range baseline {
+		if !categories[historyScope(prev.CategoryID, prev.Store)] {
+			continue
+		}
+		if _, ok := current[key]; ok {
+			continue
+		}
+		diff.Removed = append(diff.Removed, ProductChange{
+			CategoryID:   prev.CategoryID,
+			Store:        prev.Store,
+			ProductID:    prev.ProductID,
+			Name:         prev.Name,
+			CanonicalKey: prev.CanonicalKey,
+			OldPrice:     prev.Price,
+			OldPerJin:    prev.PricePerJin,
+			OldSpec:      prev.Spec,
+			Unit:         prev.Unit,
+		})
+	}
+
+	// 涨价按涨幅从大到小，降价按降幅从大到小，其余按名称
+	sort.Slice(diff.Increased, func(i, j int) bool { return diff.Increased[i].ChangePct > diff.Increased[j].ChangePct })
+	sort.Slice(diff.Decreased, func(i, j int) bool { return diff.Decreased[i].ChangePct < diff.Decreased[j].ChangePct })
+	for _, list := range [][]ProductChange{diff.New, diff.Removed, diff.SpecChanged} {
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	}
+
+	return diff
+}
+
+// setStoreNames 按页面中的分类补充变化商品的门店名称
+func (d *PriceDiff) setStoreNames(categories []Category) {
+	names := make(map[string]string)
+	for _, c := range categories {
+		names[c.Store] = c.StoreName
+	}
+	for _, list := range [][]ProductChange{d.New, d.Removed, d.Increased, d.Decreased, d.SpecChanged} {
+		for i := range list {
+			list[i].StoreName = names[list[i].Store]
+		}
+	}
+}
+
+// label 变化商品的显示名称，有门店时附带门店名称
+func (c ProductChange) label() string {
+	if c.StoreName == "" {
+		return c.Name
+	}
+	return c.Name + "（" + c.StoreName + "）"
+}
+
+// pageSnapshot 将页面数据转换为快照，返回快照和成功抓取的分类
+func pageSnapshot(data PageData) (map[string]PriceObservation, map[string]bool) {
+	snapshot := make(map[string]PriceObservation)
+	categories := make(map[string]bool)
+	for _, c := range data.Categories {
+		if c.Status == CategoryStatusFailed {
+			continue
+		}
+		categories[historyScope(c.ID, c.Store)] = true
+		for _, o := range newObservations(c.ID, c.Store, c.Products, c.FetchedAt) {
+			snapshot[o.Key()] = o
+		}
+	}
+	return snapshot, categories
+}
+
+// historyBaseline 历史中基准时刻每个商品的状态，以及基准时刻已有记录的分类
+type historyBaseline struct {
+	at       time.Time
+	state    map[string]PriceObservation
+	recorded map[string]bool // 键为 historyScope
+}
+
+// loadBaseline 回放 at 之前的历史记录，得到基准时刻的状态
+func loadBaseline(store HistoryStore, at time.Time) (*historyBaseline, error) {
+	observations, err := store.Query(HistoryQuery{Until: at})
+	if err != nil {
+		return nil, err
+	}
+	baseline := &historyBaseline{at: at, state: replayHistory(observations), recorded: make(map[string]bool)}
+	for _, o := range observations {
+		baseline.recorded[historyScope(o.CategoryID, o.Store)] = true
+	}
+	return baseline, nil
+}
+
+// diff 将当前页面数据与基准时刻的状态比较
+func (b *historyBaseline) diff(data PageData) *PriceDiff {
+	current, categories := pageSnapshot(data)
+
+	// 基准时刻还没有记录的分类（如刚启用历史存储）不参与比较，避免所有商品都显示为新上架
+	for scope := range categories {
+		if !b.recorded[scope] {
+			delete(categories, scope)
+		}
+	}
+
+	diff := diffSnapshots(b.state, current, categories)
+	diff.setStoreNames(data.Categories)
+	diff.BaselineAt = b.at
+	diff.ComparedAt = time.Now()
+	return diff
+}
+
+// diffAgainstHistory 将当前页面数据与历史中 baselineAt 时刻的状态比较
+func diffAgainstHistory(store HistoryStore, data PageData, baselineAt time.Time) (*PriceDiff, error) {
+	baseline, err := loadBaseline(store, baselineAt)
+	if err != nil {
+		return nil, err
+	}
+	return baseline.diff(data), nil
+}
+
+var (
+	todayBaselineMu sync.Mutex
+	todayBaselines  = make(map[HistoryStore]*historyBaseline) // 每个历史存储今日零点的状态
+)
+
+// todayBaseline 历史存储今日零点的状态。新记录的时间都在零点之后，零点前的状态当天不会变化，
+// 每天只读取一次历史，页面每次渲染不必重新读取整个历史文件
+func todayBaseline(store HistoryStore) (*historyBaseline, error) {
+	at := startOfDay(time.Now())
+
+	todayBaselineMu.Lock()
+	defer todayBaselineMu.Unlock()
+	if baseline, ok := todayBaselines[store]; ok && baseline.at.Equal(at) {
+		return baseline, nil
+	}
+	baseline, err := loadBaseline(store, at)
+	if err != nil {
+		return nil, err
+	}
+	todayBaselines[store] = baseline
+	return baseline, nil
+}
+
+// todayDiff 计算与今日零点前的价格相比的变化，未启用历史存储时返回nil
+func todayDiff(config Config, data PageData) *PriceDiff {
+	store, err := getHistoryStore(config.History)
+	if err != nil {
+		fmt.Printf("打开历史存储失败: %v\n", err)
+		return nil
+	}
+	if store == nil {
+		return nil
+	}
+	baseline, err := todayBaseline(store)
+	if err != nil {
+		fmt.Printf("计算价格变化失败: %v\n", err)
+		return nil
+	}
+	return baseline.diff(data)
+}
+
+// parseBaseline 解析对比基准：空值为今日零点，支持日期（2006-01-02）或时长（24h）
+func parseBaseline(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return startOfDay(now), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, chinaTime); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("无效的对比基准: %s（支持 2006-01-02 或 24h 这样的时长）", value)
+}
+
+// runDiffMode 抓取当前价格并与历史比较
+func runDiffMode(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	since := flags.String("since", "", "对比基准：日期（2006-01-02）或时长（如 24h），默认今日零点")
+	asJSON := flags.Bool("json", false, "输出JSON")
+	unitFlag := flags.String("unit", "", "价格单位：jin / kg / 500g / 100g，默认读取配置文件中的 price_unit")
+	storeFlag := flags.String("store", "", "门店ID，多个用逗号分隔，all 为所有门店，默认第一个门店")
+	flags.Parse(args)
+
+	baselineAt, err := parseBaseline(*since, time.Now())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	config, err := LoadConfig("config.json")
+	if err != nil {
+		fmt.Printf("加载配置文件失败: %v\n", err)
+		return
+	}
+	unit, err := priceUnitFor(*config, *unitFlag)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	stores, err := config.selectStores(*storeFlag)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	store, err := getHistoryStore(config.History)
+	if err != nil {
+		fmt.Printf("打开历史存储失败: %v\n", err)
+		return
+	}
+	if store == nil {
+		fmt.Println("未启用价格历史，请在配置文件中设置 history.driver")
+		return
+	}
+	defer store.Close()
+
+	data := fetchStoresConcurrently(context.Background(), *config, stores)
+	diff, err := diffAgainstHistory(store, data, baselineAt)
+	if err != nil {
+		fmt.Printf("计算价格变化失败: %v\n", err)
+		return
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(diff)
+		return
+	}
+	printPriceDiff(diff, unit)
+}
+
+// printPriceDiff 以表格形式打印价格变化，每斤价格按 unit 换算
+func printPriceDiff(diff *PriceDiff, unit PriceUnit) {
+	fmt.Printf("=== 价格变化（对比 %s）===\n", diff.BaselineAt.In(chinaTime).Format("2006-01-02 15:04"))
+	if !diff.HasChanges() {
+		fmt.Println("没有变化")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	section := func(title string, changes []ProductChange, row func(ProductChange)) {
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\n%s (%d)\n", title, len(changes))
+		for _, c := range changes {
+			row(c)
+		}
+	}
+	priceRow := func(c ProductChange) {
+		if c.OldPerJin > 0 && c.NewPerJin > 0 {
+			fmt.Fprintf(w, "  %s\t%.2f → %.2f%s\t%+.2f\t%+.1f%%\n", c.label(), unit.Convert(c.OldPerJin, c.Unit), unit.Convert(c.NewPerJin, c.Unit), unit.Display(c.Unit), unit.Convert(c.Change, c.Unit), c.ChangePct)
+		} else {
+			fmt.Fprintf(w, "  %s\t%.2f → %.2f元\t%+.2f\t%+.1f%%\n", c.label(), c.OldPrice, c.NewPrice, c.Change, c.ChangePct)
+		}
+	}
+
+	section("涨价", diff.Increased, priceRow)
+	section("降价", diff.Decreased, priceRow)
+	section("新上架", diff.New, func(c ProductChange) {
+		fmt.Fprintf(w, "  %s\t%s\t%.2f%s\n", c.label(), c.NewSpec, unit.Convert(c.NewPerJin, c.Unit), unit.Display(c.Unit))
+	})
+	section("已下架", diff.Removed, func(c ProductChange) {
+		fmt.Fprintf(w, "  %s\t%s\t%.2f%s\n", c.label(), c.OldSpec, unit.Convert(c.OldPerJin, c.Unit), unit.Display(c.Unit))
+	})
+	section("规格变化", diff.SpecChanged, func(c ProductChange) {
+		fmt.Fprintf(w, "  %s\t%s → %s\n", c.label(), c.OldSpec, c.NewSpec)
+	})
+	w.Flush()
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDiffAgainstHistory 测试与历史对比的涨价、降价、上下架和规格变化
+func TestDiffAgainstHistory(t *testing.T) {
+	store, err := openHistoryStore(HistoryConfig{Driver: HistoryDriverJSONL, Path: filepath.Join(t.TempDir(), "history.jsonl")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	yesterday := time.Date(2025, 3, 1, 9, 0, 0, 0, chinaTime)
+	store.Record("fruit-vegetable", "", []Product{
+		{ID: "p1", Name: "西红柿", Price: 3.98, Spec: "500g", PricePerJin: 3.98, Unit: "元/斤"},
+		{ID: "p2", Name: "黄瓜", Price: 2.00, Spec: "500g", PricePerJin: 2.00, Unit: "元/斤"},
+		{ID: "p3", Name: "茄子", Price: 4.00, Spec: "1斤", PricePerJin: 4.00, Unit: "元/斤"},
+		{ID: "p4", Name: "南瓜", Price: 5.00, Spec: "1个", PricePerJin: 5.00, Unit: "元/个"},
+	}, yesterday)
+
+	now := yesterday.Add(24 * time.Hour)
+	data := PageData{Categories: []Category{
+		{ID: "fruit-vegetable", Status: CategoryStatusOK, FetchedAt: now, Products: []Product{
+			{ID: "p1", Name: "西红柿", Price: 4.98, Spec: "500g", PricePerJin: 4.98, Unit: "元/斤"},
+			{ID: "p2", Name: "黄瓜", Price: 1.50, Spec: "500g", PricePerJin: 1.50, Unit: "元/斤"},
+			{ID: "p3", Name: "茄子", Price: 4.00, Spec: "2斤", PricePerJin: 2.00, Unit: "元/斤"},
+			{ID: "p5", Name: "丝瓜", Price: 3.00, Spec: "1斤", PricePerJin: 3.00, Unit: "元/斤"},
+		}},
+		// 抓取失败和没有历史的分类不参与比较
+		{ID: "leaf-vegetable", Status: CategoryStatusFailed, FetchedAt: now},
+		{ID: "mushroom", Status: CategoryStatusOK, FetchedAt: now, Products: []Product{{ID: "m1", Name: "香菇"}}},
+	}}
+
+	diff, err := diffAgainstHistory(store, data, startOfDay(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := func(changes []ProductChange) string {
+		var list []string
+		for _, c := range changes {
+			list = append(list, c.Name)
+		}
+		return strings.Join(list, ",")
+	}
+	expected := map[string]struct{ got, want string }{
+		"涨价":   {names(diff.Increased), "西红柿"},
+		"降价":   {names(diff.Decreased), "茄子,黄瓜"},
+		"新上架":  {names(diff.New), "丝瓜"},
+		"已下架":  {names(diff.Removed), "南瓜"},
+		"规格变化": {names(diff.SpecChanged), "茄子"},
+	}
+	for title, e := range expected {
+		if e.got != e.want {
+			t.Errorf("%s = %q, 期望 %q", title, e.got, e.want)
+		}
+	}
+	if c := diff.Increased[0]; c.Change != 1.00 || c.ChangePct != 25.1 {
+		t.Errorf("西红柿涨价 change=%.2f pct=%.1f, 期望 1.00 / 25.1", c.Change, c.ChangePct)
+	}
+
+	// 记录今天的抓取后，南瓜产生下架记录，回放后不再出现
+	for _, c := range data.Categories[:1] {
+		store.Record(c.ID, c.Store, c.Products, now)
+	}
+	observations, _ := store.Query(HistoryQuery{})
+	if _, ok := replayHistory(observations)["fruit-vegetable|p4"]; ok {
+		t.Error("已下架的商品回放后不应存在")
+	}
+}
+
+// TestTodayDiffBaselineCache 测试页面的价格变化当天只读取一次历史，之后的渲染使用缓存的零点状态
+func TestTodayDiffBaselineCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	config := Config{History: HistoryConfig{Driver: HistoryDriverJSONL, Path: path}}
+	store, err := getHistoryStore(config.History)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	now := time.Now()
+	store.Record("fruit-vegetable", "", []Product{{ID: "p1", Name: "西红柿", Price: 3.98, PricePerJin: 3.98, Unit: "元/斤"}}, startOfDay(now).Add(-time.Hour))
+	data := PageData{Categories: []Category{{ID: "fruit-vegetable", Status: CategoryStatusOK, FetchedAt: now, Products: []Product{
+		{ID: "p1", Name: "西红柿", Price: 4.98, PricePerJin: 4.98, Unit: "元/斤"},
+	}}}}
+	if diff := todayDiff(config, data); diff == nil || len(diff.Increased) != 1 {
+		t.Fatalf("今日价格变化 = %+v, 期望西红柿涨价", diff)
+	}
+
+	// 清空历史文件后仍使用缓存的零点状态，说明没有重新读取
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store.Record("fruit-vegetable", "", data.Categories[0].Products, now)
+	if diff := todayDiff(config, data); diff == nil || len(diff.Increased) != 1 || diff.Increased[0].OldPrice != 3.98 {
+		t.Errorf("再次渲染的价格变化 = %+v, 期望仍与零点前的 3.98 比较", diff)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WeightEstimate 按件卖的商品每件的估算重量，规格中没有重量时用于计算每斤价格
+type WeightEstimate struct {
+	Keywords []string `json:"keywords"`        // 商品名称关键词，名称中包含任意一个即匹配
+	Units    []string `json:"units,omitempty"` // 计件单位，如 个、根，为空时匹配任意计件单位
+	Jin      float64  `json:"jin"`             // 每件重量（斤）
+}
+
+// defaultWeightEstimates 内置的常见蔬菜水果每件重量，名称匹配多条时关键词最长的优先
+var defaultWeightEstimates = []WeightEstimate{
+	{Keywords: []string{"西瓜"}, Units: []string{"个"}, Jin: 8},
+	{Keywords: []string{"小西瓜", "麒麟瓜"}, Units: []string{"个"}, Jin: 4},
+	{Keywords: []string{"哈密瓜"}, Units: []string{"个"}, Jin: 3},
+	{Keywords: []string{"冬瓜"}, Units: []string{"个"}, Jin: 10},
+	{Keywords: []string{"南瓜"}, Units: []string{"个"}, Jin: 4},
+	{Keywords: []string{"贝贝南瓜"}, Units: []string{"个"}, Jin: 0.8},
+	{Keywords: []string{"柠檬"}, Units: []string{"个"}, Jin: 0.25},
+	{Keywords: []string{"西红柿", "番茄"}, Units: []string{"个"}, Jin: 0.4},
+	{Keywords: []string{"圣女果", "小番茄"}, Units: []string{"个"}, Jin: 0.03},
+	{Keywords: []string{"土豆", "马铃薯"}, Units: []string{"个"}, Jin: 0.4},
+	{Keywords: []string{"洋葱"}, Units: []string{"个"}, Jin: 0.5},
+	{Keywords: []string{"青椒", "彩椒", "甜椒"}, Units: []string{"个"}, Jin: 0.3},
+	{Keywords: []string{"茄子"}, Units: []string{"个", "根"}, Jin: 0.6},
+	{Keywords: []string{"黄瓜"}, Units: []string{"根"}, Jin: 0.4},
+	{Keywords: []string{"丝瓜", "苦瓜"}, Units: []string{"根"}, Jin: 0.6},
+	{Keywords: []string{"西葫芦"}, Units: []string{"个", "根"}, Jin: 0.8},
+	{Keywords: []string{"玉米"}, Units: []string{"根", "个"}, Jin: 0.6},
+	{Keywords: []string{"胡萝卜"}, Units: []string{"根"}, Jin: 0.3},
+	{Keywords: []string{"萝卜"}, Units: []string{"根", "个"}, Jin: 1.5},
+	{Keywords: []string{"山药"}, Units: []string{"根"}, Jin: 1.5},
+	{Keywords: []string{"莲藕"}, Units: []string{"节"}, Jin: 0.8},
+	{Keywords: []string{"大白菜"}, Units: []string{"棵", "颗", "个"}, Jin: 5},
+	{Keywords: []string{"娃娃菜"}, Units: []string{"棵", "颗", "个"}, Jin: 0.5},
+	{Keywords: []string{"包菜", "卷心菜", "圆白菜", "甘蓝"}, Units: []string{"棵", "颗", "个"}, Jin: 2},
+	{Keywords: []string{"西兰花", "花菜", "菜花", "花椰菜"}, Units: []string{"棵", "颗", "个"}, Jin: 1},
+	{Keywords: []string{"生菜"}, Units: []string{"棵", "颗"}, Jin: 0.6},
+	{Keywords: []string{"大蒜", "蒜头"}, Units: []string{"头", "个"}, Jin: 0.1},
+	{Keywords: []string{"香菜", "小葱", "香葱"}, Units: []string{"把"}, Jin: 0.1},
+	{Keywords: []string{"大葱"}, Units: []string{"根"}, Jin: 0.3},
+	{Keywords: []string{"金针菇"}, Units: []string{"袋", "包"}, Jin: 0.4},
+}
+
+// validate 检查估算重量配置，path 用于错误信息
+func (e WeightEstimate) validate(path string) error {
+	if len(e.Keywords) == 0 {
+		return fmt.Errorf("%s.keywords: 至少需要一个关键词", path)
+	}
+	for _, keyword := range e.Keywords {
+		if strings.TrimSpace(keyword) == "" {
+			return fmt.Errorf("%s.keywords: 关键词不能为空", path)
+		}
+	}
+	if e.Jin <= 0 {
+		return fmt.Errorf("%s.jin: 每件重量必须大于0", path)
+	}
+	return nil
+}
+
+// matchKeyword 返回名称中包含的最长关键词，计件单位不匹配时返回空
+func (e WeightEstimate) matchKeyword(name, unit string) string {
+	if len(e.Units) > 0 && !containsString(e.Units, unit) {
+		return ""
+	}
+	best := ""
+	for _, keyword := range e.Keywords {
+		if strings.Contains(name, keyword) && len(keyword) > len(best) {
+			best = keyword
+		}
+	}
+	return best
+}
+
+// validateWeightEstimates 检查配置中的估算重量
+func (c *Config) validateWeightEstimates() error {
+	for i, estimate := range c.WeightEstimates {
+		if err := estimate.validate(fmt.Sprintf("weight_estimates[%d]", i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findWeightEstimate 按商品名称和计件单位查找每件重量，返回重量和匹配到的关键词。
+// 配置中的规则优先于内置规则，同一组规则中关键词最长的优先，如 胡萝卜 优先于 萝卜
+func findWeightEstimate(config Config, name, unit string) (float64, string, bool) {
+	for _, estimates := range [][]WeightEstimate{config.WeightEstimates, defaultWeightEstimates} {
+		var found *WeightEstimate
+		keyword := ""
+		for i := range estimates {
+			if k := estimates[i].matchKeyword(name, unit); len(k) > len(keyword) {
+				found, keyword = &estimates[i], k
+			}
+		}
+		if found != nil {
+			return found.Jin, keyword, true
+		}
+	}
+	return 0, "", false
+}
+
+// estimateWeights 为规格中只有件数的商品按名称估算重量，计算每斤价格
+func estimateWeights(config Config, products []Product) {
+	for i := range products {
+		products[i].estimateWeight(config)
+	}
+}
+
+// estimateWeight 规格中没有重量、只有件数（如 1个、2根）时按名称估算重量，
+// 找到估算规则时按重量商品计算每斤价格，并记录估算依据
+func (p *Product) estimateWeight(config Config) {
+	info := parseSpec(p.Spec)
+	if info.PackUnit == "" || (info.HasWeight() && !info.Estimated) {
+		return
+	}
+	jin, keyword, ok := findWeightEstimate(config, p.Name, info.PackUnit)
+	if !ok {
+		return
+	}
+	count := info.Quantity
+	if count <= 0 {
+		count = 1
+	}
+
+	weight := count * jin
+	p.IsPackaged = false
+	p.Unit = unitPerJin
+	p.WeightEstimated = true
+	p.EstimatedWeight = weight
+	p.WeightBasis = fmt.Sprintf("%s 每%s约%s斤", keyword, info.PackUnit, strconv.FormatFloat(jin, 'f', -1, 64))
+	perJin := func(price float64) float64 {
+		if price <= 0 {
+			return 0
+		}
+		return price / weight
+	}
+	p.PricePerJin = perJin(p.Price)
+	p.OriginalPricePerJin = perJin(p.OriginalPrice)
+	p.MemberPricePerJin = perJin(p.MemberPrice)
+	p.PricePerGram = pricePerGram(p.PricePerJin)
+}
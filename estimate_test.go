@@ -0,0 +1,62 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestWeightEstimates 测试按商品名称估算每件重量
+func TestWeightEstimates(t *testing.T) {
+	config := Config{WeightEstimates: []WeightEstimate{
+		{Keywords: []string{"柠檬"}, Units: []string{"个"}, Jin: 0.2},
+	}}
+	products := []Product{
+		{Name: "麒麟小西瓜", Price: 20, Spec: "1个"},
+		{Name: "西瓜", Price: 32, Spec: "1个"},
+		{Name: "黄柠檬", Price: 3, Spec: "3个"},
+		{Name: "胡萝卜", Price: 0.9, Spec: "1根"},
+		{Name: "西瓜", Price: 16, Spec: "4斤"},
+		{Name: "鸡蛋", Price: 9.9, Spec: "1盒"},
+		{Name: "大白菜", Price: 5, Spec: "1颗"},
+	}
+	for i := range products {
+		products[i].updatePricePerJin()
+	}
+	estimateWeights(config, products)
+
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	tests := []struct {
+		perJin    float64
+		estimated bool
+		basis     string
+	}{
+		{5, true, "小西瓜 每个约4斤"},
+		{4, true, "西瓜 每个约8斤"},
+		{5, true, "柠檬 每个约0.2斤"}, // 配置优先于内置的 0.25斤
+		{3, true, "胡萝卜 每根约0.3斤"},
+		{4, false, ""}, // 规格中有重量时不估算
+		{9.9, false, ""},
+		{1, true, "大白菜 每颗约5斤"},
+	}
+	for i, tc := range tests {
+		p := products[i]
+		if !near(p.PricePerJin, tc.perJin) || p.WeightEstimated != tc.estimated || p.WeightBasis != tc.basis {
+			t.Errorf("%s %s: 每斤价格 %v 估算 %v 依据 %q, 期望 %v %v %q", p.Name, p.Spec, p.PricePerJin, p.WeightEstimated, p.WeightBasis, tc.perJin, tc.estimated, tc.basis)
+		}
+		if tc.estimated && (p.IsPackaged || p.Unit != "元/斤") {
+			t.Errorf("%s 估算后应按重量商品显示: %+v", p.Name, p)
+		}
+	}
+	if products[5].Unit != "元/盒" {
+		t.Errorf("没有估算规则的商品单位 = %q, 期望 元/盒", products[5].Unit)
+	}
+
+	html, err := renderProductList("", PageData{Categories: []Category{{ID: "fv", Name: "瓜果", Products: products[:1], Status: CategoryStatusOK}}})
+	if err != nil {
+		t.Fatalf("renderProductList 返回错误: %v", err)
+	}
+	if !strings.Contains(html, "≈5.00元/斤") || !strings.Contains(html, "按小西瓜 每个约4斤估算") {
+		t.Errorf("页面中没有估算的每斤价格")
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// fengzhanBaseURL 凤展超市分类页面地址，ztid 为 gfl00 加分类名称
+const fengzhanBaseURL = "https://www.fengzhansy.com/wchyzyg/wap.shtml?method=ztmodel&ztid=gfl00"
+
+func init() {
+	registerAdapter(fengzhansyAdapter{})
+}
+
+// fengzhansyAdapter 凤展超市（fengzhansy.com）微信商城
+type fengzhansyAdapter struct{}
+
+func (fengzhansyAdapter) Name() string { return "fengzhansy" }
+
+func (fengzhansyAdapter) Description() string { return "凤展超市微信商城" }
+
+// CategoryURL 没有配置url时按分类名称生成，如 叶菜类 -> ztid=gfl00叶菜类
+func (fengzhansyAdapter) CategoryURL(c CategoryConfig) (string, error) {
+	if c.URL != "" {
+		return c.URL, nil
+	}
+	if c.Name == "" {
+		return "", fmt.Errorf("分类 %s 缺少url和name，无法生成凤展超市分类地址", c.ID)
+	}
+	return fengzhanBaseURL + url.QueryEscape(c.Name), nil
+}
+
+func (fengzhansyAdapter) Fetch(ctx context.Context, config Config, url string) (*FetchResult, error) {
+	return fetchWithRetry(ctx, config, url)
+}
+
+func (a fengzhansyAdapter) Parse(ctx context.Context, config Config, body string) ([]Product, error) {
+	selectors, err := selectorsFor(config, a.Name(), a.DefaultSelectors())
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parseHTMLWithSelectors(ctx, body, selectors)
+	if err != nil {
+		return nil, err
+	}
+	products := make([]Product, 0, len(parsed))
+	for _, p := range parsed {
+		products = append(products, *p)
+	}
+	return products, nil
+}
+
+// DetailURL 商品ID为列表中商品链接的href，相对于分类页面
+func (fengzhansyAdapter) DetailURL(pageURL string, p Product) (string, error) {
+	return resolveURL(pageURL, p.ID)
+}
+
+func (a fengzhansyAdapter) ParseDetail(ctx context.Context, config Config, detailURL, body string) (ProductDetail, error) {
+	selectors, err := selectorsFor(config, a.Name(), a.DefaultSelectors())
+	if err != nil {
+		return ProductDetail{}, err
+	}
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return ProductDetail{}, err
+	}
+	return detailFromFields(selectors.extractDetail(doc), detailURL), nil
+}
+
+// Cookies 价格按门店区分，缺少门店信息时返回的是默认门店的价格或空页面
+func (fengzhansyAdapter) Cookies() []CookieRequirement {
+	return []CookieRequirement{
+		{Name: "scsmdid", Description: "门店ID，如 012", Required: true},
+		{Name: "shdzmdname", Description: "门店名称（URL编码）", Required: true},
+		{Name: "shdzarea", Description: "所在区域（URL编码）"},
+	}
+}
+
+// DefaultSelectors 内置的解析规则：商品在 div.index_picAD 的子div中，
+// 名称依次尝试 h3 / h2 / h4 / span / div。价格和规格先找类名完全为 price / spec 的元素，
+// 再找类名包含 price / spec 但不是划线原价的元素，都找不到时按文本特征查找。
+// 详情页的字段先找对应类名的元素，再按“产地：”“原价”“会员价”“库存”等文字查找
+func (fengzhansyAdapter) DefaultSelectors() SelectorConfig {
+	return SelectorConfig{
+		Container: "div[class*=index_picAD]",
+		Item:      "div",
+		Fields: map[string][]SelectorRule{
+			FieldID: {
+				{Selector: "a", Attr: "href"},
+			},
+			FieldName: {
+				{Selector: "h3"},
+				{Selector: "h2"},
+				{Selector: "h4"},
+				{Selector: "span"},
+				{Selector: "div"},
+			},
+			FieldPrice: {
+				{Selector: "span.price, div.price"},
+				{Selector: "span[class*=price]:not([class*=old])"},
+				{Selector: "div[class*=price]:not([class*=old])"},
+				{Builtin: BuiltinPriceText},
+			},
+			FieldOriginalPrice: {
+				{Selector: "del, s, [class*=old_price], [class*=price_old], [class*=market_price]"},
+			},
+			FieldMemberPrice: {
+				{Selector: "[class*=member_price], [class*=vip_price]"},
+			},
+			FieldPromo: {
+				{Selector: "[class*=promo], [class*=activity], [class*=cuxiao]"},
+			},
+			FieldSpec: {
+				{Selector: "span.spec, div.spec"},
+				{Selector: "span[class*=spec]"},
+				{Selector: "div[class*=spec]"},
+				{Selector: "span[style*=font-size:11px;]"},
+				{Builtin: BuiltinSpecText},
+				{Builtin: BuiltinSpecFromName},
+			},
+		},
+		Detail: map[string][]SelectorRule{
+			DetailImages: {
+				{Selector: "[class*=swiper] img, [class*=banner] img, [class*=goods_pic] img", Attr: "data-src"},
+				{Selector: "[class*=swiper] img, [class*=banner] img, [class*=goods_pic] img", Attr: "src"},
+			},
+			DetailOrigin: {
+				{Selector: "[class*=origin], [class*=chandi]", Regex: `(?:产地[:：]?)?\s*(\S+)`},
+				{Selector: "body", Regex: `产地\s*[:：]\s*([^\s,，;；]+)`},
+			},
+			DetailDescription: {
+				{Selector: "[class*=goods_desc], [class*=goods_detail], [class*=spxq]"},
+			},
+			DetailPrice: {
+				{Selector: "[class*=price]:not([class*=old]):not([class*=market]):not([class*=member]):not([class*=vip])"},
+			},
+			DetailOriginalPrice: {
+				{Selector: "del, s, [class*=old_price], [class*=price_old], [class*=market_price]"},
+				{Selector: "body", Regex: `原价\s*[:：]?\s*[￥¥]?\s*([0-9]+(?:\.[0-9]+)?)`},
+			},
+			DetailMemberPrice: {
+				{Selector: "[class*=member_price], [class*=vip_price]"},
+				{Selector: "body", Regex: `会员价\s*[:：]?\s*[￥¥]?\s*([0-9]+(?:\.[0-9]+)?)`},
+			},
+			DetailStock: {
+				{Selector: "[class*=stock], [class*=kucun]"},
+				{Selector: "body", Regex: `((?:库存|剩余|仅剩)\s*[:：]?\s*\d+|缺货|售罄|已售完|无货|有货)`},
+			},
+		},
+	}
+}
+
+// Debug 输出商品容器和前几个商品的解析结果
+func (a fengzhansyAdapter) Debug(config Config, htmlContent string) {
+	selectors, err := selectorsFor(config, a.Name(), a.DefaultSelectors())
+	if err != nil {
+		fmt.Printf("解析规则有误: %v\n", err)
+		return
+	}
+
+	// 解析HTML
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		fmt.Printf("解析HTML失败: %v\n", err)
+		return
+	}
+
+	// 查找商品容器
+	fmt.Printf("\n查找商品容器 %s ...\n", selectors.container)
+	items, err := selectors.items(doc)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		fmt.Println("\n搜索所有包含 'pic' 的class...")
+		findSimilarClasses(doc, "pic")
+		return
+	}
+
+	fmt.Printf("✅ 找到商品容器，其中有 %d 个 %s\n", len(items), selectors.item)
+
+	// 解析前几个商品用于调试
+	fmt.Println("\n解析前3个商品:")
+	for i, item := range items {
+		if i >= 3 {
+			break
+		}
+		fmt.Printf("\n--- 商品 %d ---\n", i+1)
+
+		// 显示商品节点的属性
+		for _, attr := range item.Attr {
+			fmt.Printf("属性: %s = %s\n", attr.Key, attr.Val)
+		}
+
+		// 解析商品信息
+		product := productFromFields(selectors.extractFields(item))
+		fmt.Printf("解析结果:\n")
+		fmt.Printf("  名称: '%s'\n", product.Name)
+		fmt.Printf("  价格: %.2f元\n", product.Price)
+		fmt.Printf("  规格: '%s'\n", product.Spec)
+
+		if product.IsPackaged {
+			fmt.Printf("  包装价格: %.2f%s\n", product.PricePerJin, product.Unit)
+		} else {
+			fmt.Printf("  每斤价格: %.2f%s\n", product.PricePerJin, product.Unit)
+		}
+
+		// 显示原始文本内容
+		rawText := getTextContent(item)
+		fmt.Printf("  原始文本: '%s'\n", strings.TrimSpace(rawText))
+	}
+}
+
+// parseHTML 使用内置规则解析凤展超市页面，提取所有商品信息
+func parseHTML(ctx context.Context, htmlContent string) ([]*Product, error) {
+	a := fengzhansyAdapter{}
+	selectors, err := compileSelectors(a.DefaultSelectors(), SelectorConfig{}, "selectors."+a.Name())
+	if err != nil {
+		return nil, err
+	}
+	return parseHTMLWithSelectors(ctx, htmlContent, selectors)
+}
+
+// parseHTMLWithSelectors 按解析规则提取页面中的所有商品
+func parseHTMLWithSelectors(ctx context.Context, htmlContent string, selectors *productSelectors) ([]*Product, error) {
+	var products []*Product
+
+	// 解析HTML
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := selectors.items(doc)
+	if err != nil {
+		return products, err
+	}
+
+	for _, item := range items {
+		// 调用方已取消，停止解析
+		if err := ctx.Err(); err != nil {
+			return products, err
+		}
+		product := productFromFields(selectors.extractFields(item))
+		if product.Name != "" || product.Price > 0 {
+			products = append(products, product)
+		}
+	}
+
+	return products, nil
+}
+
+// productFromFields 由提取到的字段生成商品，并计算每斤价格
+func productFromFields(fields map[string]string) *Product {
+	product := &Product{
+		ID:   fields[FieldID],
+		Name: fields[FieldName],
+		Spec: fields[FieldSpec],
+	}
+
+	// 价格文本中可能同时有售价、原价和会员价，单独的原价、会员价元素优先
+	info := parsePriceText(fields[FieldPrice])
+	product.Price = info.Price
+	product.OriginalPrice = info.OriginalPrice
+	product.MemberPrice = info.MemberPrice
+	product.PromoLabel = info.PromoLabel
+	if price := parsePriceText(fields[FieldOriginalPrice]).Price; price > 0 {
+		product.OriginalPrice = price
+	}
+	if price := parsePriceText(fields[FieldMemberPrice]).Price; price > 0 {
+		product.MemberPrice = price
+	}
+	if promo := fields[FieldPromo]; promo != "" {
+		product.PromoLabel = promo
+	}
+
+	// 判断是否为包装商品并计算价格
+	product.updatePricePerJin()
+	return product
+}
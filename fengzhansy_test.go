@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// TestParseFengzhansyPage 测试内置规则解析凤展超市页面：划线原价单独记录，规格按样式或类名查找，
+// 价格上的 vip 等样式类不算会员价
+func TestParseFengzhansyPage(t *testing.T) {
+	products, err := parseHTML(context.Background(), fengzhansyListPage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Product{
+		{ID: "wap.shtml?method=spxq&spid=1001", Name: "菠菜", Price: 3.98, Spec: "500g", PricePerJin: 3.98, PricePerGram: 3.98 / 500, Unit: "元/斤", OriginalPrice: 4.98, OriginalPricePerJin: 4.98},
+		{ID: "wap.shtml?method=spxq&spid=1002", Name: "小白菜 约1斤", Price: 2.50, Spec: "1斤", PricePerJin: 2.50, PricePerGram: 2.50 / 500, Unit: "元/斤", OriginalPrice: 3.50, OriginalPricePerJin: 3.50},
+		{ID: "wap.shtml?method=spxq&spid=1003", Name: "生菜", Price: 2.00, Spec: "250g", PricePerJin: 4.00, PricePerGram: 4.00 / 500, Unit: "元/斤"},
+		{ID: "wap.shtml?method=spxq&spid=1004", Name: "香菜", Price: 1.50, Spec: "250g", PricePerJin: 3.00, PricePerGram: 3.00 / 500, Unit: "元/斤"},
+	}
+	var got []Product
+	for _, p := range products {
+		got = append(got, *p)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("解析结果 = %+v\n期望 %+v", got, want)
+	}
+	// 生菜的价格类名为 price vip，不应同时当作会员价
+	for _, p := range got {
+		if p.MemberPrice != 0 || p.MemberPricePerJin != 0 {
+			t.Errorf("%s 不应有会员价: %v / %v", p.Name, p.MemberPrice, p.MemberPricePerJin)
+		}
+	}
+}
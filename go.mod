@@ -4,5 +4,8 @@ go 1.24.4
 
 require (
 	github.com/aliyun/fc-runtime-go-sdk v0.3.1
+	go.etcd.io/bbolt v1.4.3
 	golang.org/x/net v0.43.0
 )
+
+require golang.org/x/sys v0.35.0 // indirect
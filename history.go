@@ -0,0 +1,518 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// 历史记录存储类型
+const (
+	HistoryDriverNone  = "none"  // 不保存历史
+	HistoryDriverJSONL = "jsonl" // 追加写入的JSON Lines文件
+	HistoryDriverBolt  = "bolt"  // bbolt嵌入式数据库
+)
+
+// PriceObservation 一次价格观测记录
+type PriceObservation struct {
+	ObservedAt   time.Time `json:"observed_at"`             // 观测时间
+	CategoryID   string    `json:"category_id"`             // 分类ID
+	Store        string    `json:"store,omitempty"`         // 门店ID，未配置门店时为空
+	ProductID    string    `json:"product_id"`              // 商品ID
+	Name         string    `json:"name"`                    // 商品名称
+	CanonicalKey string    `json:"canonical_key,omitempty"` // 规范名称
+	Price        float64   `json:"price"`                   // 价格
+	Spec         string    `json:"spec"`                    // 规格
+	PricePerJin  float64   `json:"price_per_jin"`           // 每斤价格
+	Unit         string    `json:"unit"`                    // 价格单位
+	Removed      bool      `json:"removed,omitempty"`       // 商品已下架（分类抓取成功但没有该商品）
+}
+
+// Key 商品在历史记录中的唯一标识，商品ID为空时使用名称
+func (o PriceObservation) Key() string {
+	id := o.ProductID
+	if id == "" {
+		id = o.Name
+	}
+	return historyScope(o.CategoryID, o.Store) + "|" + id
+}
+
+// historyScope 分类在历史记录中的范围，不同门店的同一分类分开记录
+func historyScope(categoryID, store string) string {
+	if store == "" {
+		return categoryID
+	}
+	return categoryID + "@" + store
+}
+
+// sameAs 判断与上一次观测相比价格和规格是否没有变化
+func (o PriceObservation) sameAs(prev PriceObservation) bool {
+	return o.Removed == prev.Removed &&
+		o.Name == prev.Name &&
+		o.Price == prev.Price &&
+		o.Spec == prev.Spec &&
+		o.PricePerJin == prev.PricePerJin &&
+		o.Unit == prev.Unit
+}
+
+// HistoryQuery 历史记录查询条件，零值表示不限制
+type HistoryQuery struct {
+	CategoryID string    // 分类ID
+	Store      string    // 门店ID
+	Name       string    // 商品名称关键词
+	Key        string    // 规范名称，与名称关键词满足其一即可，用于查到改名前的记录
+	Since      time.Time // 起始时间（含）
+	Until      time.Time // 结束时间（不含）
+}
+
+// match 判断观测记录是否满足查询条件
+func (q HistoryQuery) match(o PriceObservation) bool {
+	if q.CategoryID != "" && o.CategoryID != q.CategoryID {
+		return false
+	}
+	if q.Store != "" && o.Store != q.Store {
+		return false
+	}
+	if q.Name != "" && !strings.Contains(o.Name, q.Name) && (q.Key == "" || o.CanonicalKey != q.Key) {
+		return false
+	}
+	if !q.Since.IsZero() && o.ObservedAt.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !o.ObservedAt.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// HistoryStore 价格历史存储
+type HistoryStore interface {
+	// Record 记录一个门店的分类抓取结果，跳过与上次观测相同的商品，返回实际写入的条数。
+	// 未配置门店时 store 为空
+	Record(categoryID, store string, products []Product, at time.Time) (int, error)
+	// Query 按时间顺序返回满足条件的观测记录
+	Query(q HistoryQuery) ([]PriceObservation, error)
+	// Close 关闭存储
+	Close() error
+}
+
+// newObservations 将商品转换为观测记录
+func newObservations(categoryID, store string, products []Product, at time.Time) []PriceObservation {
+	observations := make([]PriceObservation, 0, len(products))
+	for _, p := range products {
+		observations = append(observations, PriceObservation{
+			ObservedAt:   at,
+			CategoryID:   categoryID,
+			Store:        store,
+			ProductID:    p.ID,
+			Name:         p.Name,
+			CanonicalKey: p.CanonicalKey,
+			Price:        p.Price,
+			Spec:         p.Spec,
+			PricePerJin:  p.PricePerJin,
+			Unit:         p.Unit,
+		})
+	}
+	return observations
+}
+
+// removedObservations 为上次还在、本次分类中已没有的商品生成下架记录
+func removedObservations(categoryID, store string, current []PriceObservation, last map[string]PriceObservation, at time.Time) []PriceObservation {
+	present := make(map[string]bool, len(current))
+	for _, o := range current {
+		present[o.Key()] = true
+	}
+
+	var removed []PriceObservation
+	for key, prev := range last {
+		if prev.CategoryID != categoryID || prev.Store != store || prev.Removed || present[key] {
+			continue
+		}
+		tombstone := prev
+		tombstone.ObservedAt = at
+		tombstone.Removed = true
+		removed = append(removed, tombstone)
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i].Key() < removed[j].Key() })
+	return removed
+}
+
+// replayHistory 按时间顺序回放观测记录，得到每个商品的最新状态（不含已下架商品）
+func replayHistory(observations []PriceObservation) map[string]PriceObservation {
+	state := make(map[string]PriceObservation)
+	for _, o := range observations {
+		if o.Removed {
+			delete(state, o.Key())
+		} else {
+			state[o.Key()] = o
+		}
+	}
+	return state
+}
+
+// openHistoryStore 根据配置打开历史存储，未启用时返回nil
+func openHistoryStore(cfg HistoryConfig) (HistoryStore, error) {
+	switch cfg.Driver {
+	case "", HistoryDriverNone:
+		return nil, nil
+	case HistoryDriverJSONL:
+		return openJSONLHistory(cfg.Path)
+	case HistoryDriverBolt:
+		return openBoltHistory(cfg.Path)
+	default:
+		return nil, fmt.Errorf("不支持的历史存储类型: %s", cfg.Driver)
+	}
+}
+
+var (
+	historyMu     sync.Mutex
+	historyStores = make(map[string]HistoryStore)
+)
+
+// getHistoryStore 获取共享的历史存储，同一文件只打开一次
+func getHistoryStore(cfg HistoryConfig) (HistoryStore, error) {
+	if cfg.Driver == "" || cfg.Driver == HistoryDriverNone {
+		return nil, nil
+	}
+
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	key := cfg.Driver + ":" + cfg.Path
+	if store, ok := historyStores[key]; ok {
+		return store, nil
+	}
+	store, err := openHistoryStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	historyStores[key] = store
+	return store, nil
+}
+
+// recordHistory 保存分类的抓取结果，失败只打印日志，不影响页面输出
+func recordHistory(config Config, category Category) {
+	store, err := getHistoryStore(config.History)
+	if err != nil {
+		fmt.Printf("打开历史存储失败: %v\n", err)
+		return
+	}
+	if store == nil {
+		return
+	}
+	if _, err := store.Record(category.ID, category.Store, category.Products, category.FetchedAt); err != nil {
+		fmt.Printf("保存分类 %s 的价格历史失败: %v\n", category.Name, err)
+	}
+}
+
+// jsonlHistory 追加写入的JSON Lines历史文件，每行一条观测记录
+type jsonlHistory struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	last map[string]PriceObservation // 每个商品最近一次观测，用于去重
+}
+
+func openJSONLHistory(path string) (*jsonlHistory, error) {
+	if path == "" {
+		path = "history.jsonl"
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("创建历史目录失败: %v", err)
+	}
+
+	h := &jsonlHistory{path: path, last: make(map[string]PriceObservation)}
+
+	// 读取已有记录，恢复每个商品的最近一次观测
+	observations, err := h.readAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range observations {
+		h.last[o.Key()] = o
+	}
+
+	h.file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("打开历史文件失败: %v", err)
+	}
+	return h, nil
+}
+
+// readAll 读取文件中的所有观测记录，跳过无法解析的行
+func (h *jsonlHistory) readAll() ([]PriceObservation, error) {
+	f, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取历史文件失败: %v", err)
+	}
+	defer f.Close()
+
+	var observations []PriceObservation
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var o PriceObservation
+		if err := json.Unmarshal(scanner.Bytes(), &o); err != nil {
+			// 写入中断留下的半行
+			continue
+		}
+		observations = append(observations, o)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取历史文件失败: %v", err)
+	}
+	return observations, nil
+}
+
+func (h *jsonlHistory) Record(categoryID, store string, products []Product, at time.Time) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var buf []byte
+	var changed []PriceObservation
+	current := newObservations(categoryID, store, products, at)
+	for _, o := range append(current, removedObservations(categoryID, store, current, h.last, at)...) {
+		if prev, ok := h.last[o.Key()]; ok && o.sameAs(prev) {
+			continue
+		}
+		line, err := json.Marshal(o)
+		if err != nil {
+			return 0, err
+		}
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+		changed = append(changed, o)
+	}
+	if len(changed) == 0 {
+		return 0, nil
+	}
+
+	// 一次写入整批记录
+	if _, err := h.file.Write(buf); err != nil {
+		return 0, fmt.Errorf("写入历史文件失败: %v", err)
+	}
+	for _, o := range changed {
+		h.last[o.Key()] = o
+	}
+	return len(changed), nil
+}
+
+func (h *jsonlHistory) Query(q HistoryQuery) ([]PriceObservation, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	observations, err := h.readAll()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]PriceObservation, 0)
+	for _, o := range observations {
+		if q.match(o) {
+			result = append(result, o)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].ObservedAt.Before(result[j].ObservedAt)
+	})
+	return result, nil
+}
+
+func (h *jsonlHistory) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.file.Close()
+}
+
+var (
+	boltObservationsBucket = []byte("observations") // 所有观测记录，按写入顺序
+	boltLatestBucket       = []byte("latest")       // 每个商品最近一次观测，用于去重
+)
+
+// boltHistory 基于bbolt的历史存储
+type boltHistory struct {
+	db *bolt.DB
+}
+
+func openBoltHistory(path string) (*boltHistory, error) {
+	if path == "" {
+		path = "history.db"
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("创建历史目录失败: %v", err)
+	}
+
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("打开历史数据库失败: %v", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(boltObservationsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(boltLatestBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化历史数据库失败: %v", err)
+	}
+	return &boltHistory{db: db}, nil
+}
+
+func (h *boltHistory) Record(categoryID, store string, products []Product, at time.Time) (int, error) {
+	written := 0
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		observations := tx.Bucket(boltObservationsBucket)
+		latest := tx.Bucket(boltLatestBucket)
+
+		// 读取该分类每个商品的最近一次观测，用于生成下架记录
+		last := make(map[string]PriceObservation)
+		prefix := []byte(historyScope(categoryID, store) + "|")
+		cursor := latest.Cursor()
+		for k, v := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cursor.Next() {
+			var prev PriceObservation
+			if json.Unmarshal(v, &prev) == nil {
+				last[string(k)] = prev
+			}
+		}
+
+		current := newObservations(categoryID, store, products, at)
+		for _, o := range append(current, removedObservations(categoryID, store, current, last, at)...) {
+			key := []byte(o.Key())
+			if data := latest.Get(key); data != nil {
+				var prev PriceObservation
+				if json.Unmarshal(data, &prev) == nil && o.sameAs(prev) {
+					continue
+				}
+			}
+
+			value, err := json.Marshal(o)
+			if err != nil {
+				return err
+			}
+			seq, err := observations.NextSequence()
+			if err != nil {
+				return err
+			}
+			id := make([]byte, 8)
+			binary.BigEndian.PutUint64(id, seq)
+			if err := observations.Put(id, value); err != nil {
+				return err
+			}
+			if err := latest.Put(key, value); err != nil {
+				return err
+			}
+			written++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("写入历史数据库失败: %v", err)
+	}
+	return written, nil
+}
+
+func (h *boltHistory) Query(q HistoryQuery) ([]PriceObservation, error) {
+	result := make([]PriceObservation, 0)
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltObservationsBucket).ForEach(func(_, value []byte) error {
+			var o PriceObservation
+			if err := json.Unmarshal(value, &o); err != nil {
+				return nil
+			}
+			if q.match(o) {
+				result = append(result, o)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询历史数据库失败: %v", err)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].ObservedAt.Before(result[j].ObservedAt)
+	})
+	return result, nil
+}
+
+func (h *boltHistory) Close() error {
+	return h.db.Close()
+}
+
+// runHistoryMode 查询价格历史
+// 用法: history <商品名称关键词> [天数]
+func runHistoryMode(args []string) {
+	flags := flag.NewFlagSet("history", flag.ExitOnError)
+	unitFlag := flags.String("unit", "", "价格单位：jin / kg / 500g / 100g，默认读取配置文件中的 price_unit")
+	storeFlag := flags.String("store", "", "只查询该门店的记录，默认查询所有门店")
+	flags.Parse(args)
+	args = flags.Args()
+	if len(args) == 0 {
+		fmt.Println("用法: vegetable-price history [-unit kg] [-store 门店ID] <商品名称关键词> [天数，默认7]")
+		return
+	}
+
+	days := 7
+	if len(args) > 1 {
+		if _, err := fmt.Sscanf(args[1], "%d", &days); err != nil || days <= 0 {
+			fmt.Printf("无效的天数: %s\n", args[1])
+			return
+		}
+	}
+
+	config, err := LoadConfig("config.json")
+	if err != nil {
+		fmt.Printf("加载配置文件失败: %v\n", err)
+		return
+	}
+	unit, err := priceUnitFor(*config, *unitFlag)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	store, err := getHistoryStore(config.History)
+	if err != nil {
+		fmt.Printf("打开历史存储失败: %v\n", err)
+		return
+	}
+	if store == nil {
+		fmt.Println("未启用价格历史，请在配置文件中设置 history.driver")
+		return
+	}
+	defer store.Close()
+
+	observations, err := store.Query(HistoryQuery{
+		Name:  args[0],
+		Key:   catalogFor(*config).Key(args[0]),
+		Store: *storeFlag,
+		Since: time.Now().AddDate(0, 0, -days),
+	})
+	if err != nil {
+		fmt.Printf("查询价格历史失败: %v\n", err)
+		return
+	}
+	if len(observations) == 0 {
+		fmt.Printf("最近%d天没有 %s 的价格记录\n", days, args[0])
+		return
+	}
+
+	fmt.Printf("=== %s 最近%d天的价格记录 ===\n", args[0], days)
+	for _, o := range observations {
+		fmt.Printf("%s  %-20s  %6.2f元  %-10s  %.2f%s  %s\n",
+			o.ObservedAt.Format("2006-01-02 15:04"), o.Name, o.Price, o.Spec, unit.Convert(o.PricePerJin, o.Unit), unit.Display(o.Unit), storeName(*config, o.Store))
+	}
+}
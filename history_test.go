@@ -0,0 +1,61 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestHistoryStore 测试两种历史存储的写入去重、重新打开和查询
+func TestHistoryStore(t *testing.T) {
+	dir := t.TempDir()
+	day1 := time.Date(2025, 3, 1, 8, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+	day3 := day1.AddDate(0, 0, 2)
+
+	products := []Product{
+		{ID: "p1", Name: "西红柿", Price: 3.98, Spec: "500g", PricePerJin: 3.98, Unit: "元/斤"},
+		{ID: "p2", Name: "土豆", Price: 1.50, Spec: "1斤", PricePerJin: 1.50, Unit: "元/斤"},
+	}
+
+	for _, driver := range []string{HistoryDriverJSONL, HistoryDriverBolt} {
+		cfg := HistoryConfig{Driver: driver, Path: filepath.Join(dir, driver, "history")}
+		store, err := openHistoryStore(cfg)
+		if err != nil {
+			t.Fatalf("[%s] 打开历史存储失败: %v", driver, err)
+		}
+
+		if n, err := store.Record("fruit-vegetable", "", products, day1); err != nil || n != 2 {
+			t.Errorf("[%s] 首次记录 n=%d err=%v, 期望写入2条", driver, n, err)
+		}
+		// 价格未变化，不重复写入
+		if n, err := store.Record("fruit-vegetable", "", products, day2); err != nil || n != 0 {
+			t.Errorf("[%s] 重复记录 n=%d err=%v, 期望写入0条", driver, n, err)
+		}
+		store.Close()
+
+		// 重新打开后仍能去重
+		store, err = openHistoryStore(cfg)
+		if err != nil {
+			t.Fatalf("[%s] 重新打开历史存储失败: %v", driver, err)
+		}
+		changed := []Product{products[0], products[1]}
+		changed[0].Price, changed[0].PricePerJin = 2.98, 2.98
+		if n, err := store.Record("fruit-vegetable", "", changed, day3); err != nil || n != 1 {
+			t.Errorf("[%s] 降价后记录 n=%d err=%v, 期望写入1条", driver, n, err)
+		}
+
+		observations, err := store.Query(HistoryQuery{Name: "西红柿"})
+		if err != nil {
+			t.Fatalf("[%s] 查询失败: %v", driver, err)
+		}
+		if len(observations) != 2 || observations[0].Price != 3.98 || observations[1].Price != 2.98 {
+			t.Errorf("[%s] 西红柿历史 = %+v", driver, observations)
+		}
+		observations, _ = store.Query(HistoryQuery{Since: day2})
+		if len(observations) != 1 {
+			t.Errorf("[%s] day2之后的记录数 = %d, 期望1", driver, len(observations))
+		}
+		store.Close()
+	}
+}
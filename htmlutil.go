@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// getTextContent 获取节点的文本内容
+func getTextContent(n *html.Node) string {
+	var result string
+
+	var traverse func(*html.Node)
+	traverse = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			result += node.Data
+		}
+
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+	}
+
+	traverse(n)
+	return result
+}
+
+// findPriceText 在节点中查找价格文本
+func findPriceText(n *html.Node) string {
+	var priceTexts []string
+
+	var traverse func(*html.Node)
+	traverse = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			text := strings.TrimSpace(node.Data)
+			// 查找包含价格相关字符的文本
+			if strings.Contains(text, "元") || strings.Contains(text, "￥") || strings.Contains(text, "$") {
+				// 检查是否包含数字
+				if regexp.MustCompile(`\d+(\.\d+)?`).MatchString(text) {
+					priceTexts = append(priceTexts, text)
+				}
+			}
+		}
+
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+	}
+
+	traverse(n)
+
+	// 如果找到多个价格文本，选择第一个包含￥的
+	for _, text := range priceTexts {
+		if strings.Contains(text, "￥") {
+			return text
+		}
+	}
+
+	// 如果没有￥，返回第一个
+	if len(priceTexts) > 0 {
+		return priceTexts[0]
+	}
+
+	return ""
+}
+
+// findSpecText 在节点中查找规格文本
+func findSpecText(n *html.Node) string {
+	var specText string
+
+	var traverse func(*html.Node)
+	traverse = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			text := strings.TrimSpace(node.Data)
+			// 查找包含重量单位的文本
+			weightUnits := []string{"斤", "kg", "千克", "g", "克", "两", "磅"}
+			for _, unit := range weightUnits {
+				if strings.Contains(text, unit) {
+					// 检查是否包含数字
+					if regexp.MustCompile(`\d+(\.\d+)?`).MatchString(text) {
+						if specText == "" {
+							specText = text
+						}
+					}
+				}
+			}
+		}
+
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+	}
+
+	traverse(n)
+	return specText
+}
+
+// findSimilarClasses 查找相似的class名
+func findSimilarClasses(n *html.Node, keyword string) {
+	var traverse func(*html.Node)
+	traverse = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			for _, attr := range node.Attr {
+				if attr.Key == "class" && strings.Contains(strings.ToLower(attr.Val), strings.ToLower(keyword)) {
+					fmt.Printf("找到相似class: %s (标签: %s)\n", attr.Val, node.Data)
+				}
+			}
+		}
+
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+	}
+
+	traverse(n)
+}
@@ -1,33 +1,55 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
+	"os/signal"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
-	"text/template"
+	"syscall"
 	"time"
 
-	"golang.org/x/net/html"
-
 	"github.com/aliyun/fc-runtime-go-sdk/fc"
 )
 
 type Product struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`          // 商品名称
-	Price       float64 `json:"price"`         // 价格
-	Spec        string  `json:"spec"`          // 规格
-	PricePerJin float64 `json:"price_per_jin"` // 每斤价格
-	IsPackaged  bool    `json:"is_packaged"`   // 是否为包装商品（盒装、袋装等）
-	Unit        string  `json:"unit"`          // 价格单位（元/斤、元/盒、元/袋等）
+	ID           string  `json:"id"`
+	Name         string  `json:"name"`                     // 商品名称
+	CanonicalKey string  `json:"canonical_key"`            // 规范名称，去掉营销词并合并同物异名，如 【精品】番茄约500g -> 西红柿
+	Price        float64 `json:"price"`                    // 价格
+	Spec         string  `json:"spec"`                     // 规格
+	PricePerJin  float64 `json:"price_per_jin"`            // 每斤价格
+	PricePerGram float64 `json:"price_per_gram,omitempty"` // 每克价格，按重量计算的商品才有，换算其他单位时以此为准
+	IsPackaged   bool    `json:"is_packaged"`              // 是否为包装商品（盒装、袋装等）
+	Unit         string  `json:"unit"`                     // 价格单位（元/斤、元/盒、元/袋等）
+
+	// 促销和会员价格，页面中没有时为空；每斤价格与 price_per_jin 的计算方式相同
+	OriginalPrice       float64 `json:"original_price,omitempty"`         // 原价（划线价），促销时高于价格
+	OriginalPricePerJin float64 `json:"original_price_per_jin,omitempty"` // 原价的每斤价格
+	MemberPrice         float64 `json:"member_price,omitempty"`           // 会员价
+	MemberPricePerJin   float64 `json:"member_price_per_jin,omitempty"`   // 会员价的每斤价格
+	PromoLabel          string  `json:"promo_label,omitempty"`            // 促销标签，如 特价、秒杀价、8.8折
+
+	// 以下字段来自商品详情页，未开启详情抓取或页面中没有时为空
+	DetailURL     string   `json:"detail_url,omitempty"`     // 详情页地址
+	Images        []string `json:"images,omitempty"`         // 商品图片
+	Origin        string   `json:"origin,omitempty"`         // 产地
+	Description   string   `json:"description,omitempty"`    // 商品描述
+	Stock         string   `json:"stock,omitempty"`          // 库存状态：in_stock / out_of_stock
+	StockQuantity int      `json:"stock_quantity,omitempty"` // 库存数量，页面未显示时为0
+
+	// 按件卖的商品按名称估算重量时，每斤价格是估算值
+	WeightEstimated bool    `json:"weight_estimated,omitempty"` // 每斤价格是否按估算重量计算
+	EstimatedWeight float64 `json:"estimated_weight,omitempty"` // 估算的总重量（斤）
+	WeightBasis     string  `json:"weight_basis,omitempty"`     // 估算依据，如 西瓜 每个约8斤
+
+	// 配置了多个门店时商品所在的门店，未配置门店时为空
+	Store     string `json:"store,omitempty"`      // 门店ID
+	StoreName string `json:"store_name,omitempty"` // 门店名称
 }
 
 // AliyunFunctionResponse 阿里云函数响应结构
@@ -41,19 +63,83 @@ type AliyunFunctionResponse struct {
 type AliyunFunctionRequest struct {
 	URL    string `json:"url"`
 	Cookie string `json:"cookie"`
-	Mode   string `json:"mode"` // "normal", "debug", "test"
+	Mode   string `json:"mode"`  // "normal", "debug", "test"
+	Unit   string `json:"unit"`  // 价格单位：jin / kg / 500g / 100g，为空时使用配置中的 price_unit
+	Store  string `json:"store"` // 门店ID，多个用逗号分隔，all 为所有门店，为空时为第一个门店
+	List   string `json:"list"`  // 购物清单，如 西红柿 2斤, 土豆 3斤，不为空时页面显示清单的花费
+
+	Watchlist string `json:"watchlist"` // 收藏夹ID，不为空时页面从服务端收藏夹读取收藏
+
+	Format string `json:"format"` // 输出格式：html / json / csv，为空时按 accept 协商
+	Accept string `json:"accept"` // HTTP请求的 Accept 请求头，用于协商输出格式
+
+	serve  bool    // 由 serve 模式调用，页面可以使用 /static/ 和 /api/ 路由
+	config *Config // serve 模式启动时加载的配置，为空时读取 config.json
+}
+
+// loadConfig 请求使用的配置：serve 模式使用启动时加载的配置，阿里云函数每次读取 config.json
+func (r AliyunFunctionRequest) loadConfig() (*Config, error) {
+	if r.config != nil {
+		return r.config, nil
+	}
+	return LoadConfig("config.json")
 }
 
+// errorBody 生成阿里云函数响应的JSON错误信息，错误信息中的引号、反斜杠等字符会正确转义
+func errorBody(format string, args ...interface{}) string {
+	body, _ := json.Marshal(map[string]string{"error": fmt.Sprintf(format, args...)})
+	return string(body)
+}
+
+// 分类抓取状态
+const (
+	CategoryStatusOK     = "ok"     // 抓取成功
+	CategoryStatusFailed = "failed" // 抓取失败
+	CategoryStatusStale  = "stale"  // 使用的是之前抓取的旧数据
+)
+
 // 定义分类结构体
 type Category struct {
-	ID       string    `json:"id"`
-	Name     string    `json:"name"`
-	Products []Product `json:"products"`
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Adapter    string    `json:"adapter"`              // 网站适配器
+	Store      string    `json:"store,omitempty"`      // 门店ID，未配置门店时为空
+	StoreName  string    `json:"store_name,omitempty"` // 门店名称
+	Products   []Product `json:"products"`
+	Status     string    `json:"status"`          // 抓取状态：ok / failed / stale
+	Error      string    `json:"error,omitempty"` // 抓取失败时的错误信息
+	DurationMs int64     `json:"duration_ms"`     // 抓取耗时（毫秒）
+	FetchedAt  time.Time `json:"fetched_at"`      // 抓取时间
+
+	FromCache       bool  `json:"from_cache"`        // 是否来自缓存
+	CacheAgeSeconds int64 `json:"cache_age_seconds"` // 缓存数据距抓取时的秒数
+}
+
+// CategoryError 分类抓取错误
+type CategoryError struct {
+	CategoryID   string    `json:"category_id"`
+	CategoryName string    `json:"category_name"`
+	Store        string    `json:"store,omitempty"`
+	StoreName    string    `json:"store_name,omitempty"`
+	Status       string    `json:"status"`
+	Message      string    `json:"message"`
+	FetchedAt    time.Time `json:"fetched_at"`
 }
 
 // 定义页面数据结构
 type PageData struct {
-	Categories []Category `json:"categories"`
+	Categories    []Category        `json:"categories"`
+	Errors        []CategoryError   `json:"errors"`               // 抓取失败的分类
+	Diff          *PriceDiff        `json:"diff,omitempty"`       // 与今日零点前相比的价格变化，未启用历史存储时为空
+	PriceUnit     PriceUnit         `json:"price_unit"`           // 重量商品价格的显示单位，商品中的每克价格按此换算
+	Stores        []StoreConfig     `json:"stores,omitempty"`     // 配置的所有门店，用于页面中的门店选择
+	Store         string            `json:"store,omitempty"`      // 本次选择的门店参数，与请求中的 store 相同
+	Comparison    *ComparisonReport `json:"comparison,omitempty"` // 跨门店比价，页面中有多个门店或网站的数据时才有
+	Shopping      *ShoppingResult   `json:"shopping,omitempty"`   // 购物清单的花费，请求中有清单时才有
+	List          string            `json:"-"`                    // 请求中的购物清单，用于回填页面中的表单
+	Watchlist     *Watchlist        `json:"watchlist,omitempty"`  // 服务端收藏夹，请求中有收藏夹ID时才有
+	StaticURL     string            `json:"-"`                    // 页面引用静态文件的URL前缀，为空时内联CSS和图标字体
+	WatchlistSync bool              `json:"-"`                    // 页面可以调用收藏夹接口（serve 模式），否则收藏只保存在浏览器本地
 }
 
 func main() {
@@ -85,6 +171,30 @@ func runLocalMode() {
 			// 运行调试模式
 			runDebugMode()
 			return
+		case "serve":
+			// 运行HTTP服务模式
+			runServeMode(os.Args[2:])
+			return
+		case "history":
+			// 查询价格历史
+			runHistoryMode(os.Args[2:])
+			return
+		case "diff":
+			// 对比价格变化
+			runDiffMode(os.Args[2:])
+			return
+		case "alerts":
+			// 检查价格提醒
+			runAlertsMode(os.Args[2:])
+			return
+		case "compare":
+			// 跨门店比价
+			runCompareMode(os.Args[2:])
+			return
+		case "shopping":
+			// 计算购物清单花费
+			runShoppingMode(os.Args[2:])
+			return
 		}
 	}
 
@@ -101,8 +211,12 @@ func runLocalMode() {
 	fmt.Printf("重试次数: %d次\n", config.RetryCount)
 	fmt.Println("正在获取商品信息...")
 
+	// Ctrl+C 时取消所有进行中的请求
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// 获取所有商品信息
-	products, err := fetchAllProductInfo(config.UrlFv, config.Cookie)
+	products, err := fetchCategoryProducts(ctx, *config, config.DefaultCategory())
 
 	if err != nil {
 		fmt.Printf("获取商品信息失败: %v\n", err)
@@ -115,6 +229,8 @@ func runLocalMode() {
 		return
 	}
 
+	// 配置已校验过，单位一定有效
+	unit, _ := parsePriceUnit(config.PriceUnit)
 	fmt.Printf("\n=== 找到 %d 个商品 ===\n", len(products))
 	for i, product := range products {
 		fmt.Printf("\n--- 商品 %d ---\n", i+1)
@@ -127,17 +243,17 @@ func runLocalMode() {
 			// 包装商品显示包装价格
 			fmt.Printf("包装价格: %.2f%s\n", product.PricePerJin, product.Unit)
 		} else {
-			// 重量商品显示每斤价格
+			// 重量商品按配置的单位显示价格
 			if product.PricePerJin > 0 {
-				fmt.Printf("每斤价格: %.2f%s\n", product.PricePerJin, product.Unit)
+				fmt.Printf("每%s价格: %.2f%s\n", unit.Name(), unit.Convert(product.PricePerJin, product.Unit), unit.Display(product.Unit))
 			} else {
-				fmt.Printf("每斤价格: 无法计算\n")
+				fmt.Printf("每%s价格: 无法计算\n", unit.Name())
 			}
 		}
 	}
 
-	out, err := HandleHttpRequestWithHtml(context.Background(), AliyunFunctionRequest{
-		URL:    config.UrlFv,
+	out, err := HandleHttpRequestWithHtml(ctx, AliyunFunctionRequest{
+		URL:    categoryURL(config.DefaultCategory()),
 		Cookie: config.Cookie,
 		Mode:   "normal",
 	})
@@ -162,12 +278,12 @@ func HandleRequest(ctx context.Context, request AliyunFunctionRequest) (AliyunFu
 	var result interface{}
 	var err error
 
-	config, err := LoadConfig("config.json")
+	config, err := request.loadConfig()
 	if err != nil {
 		return AliyunFunctionResponse{
 			StatusCode: 500,
 			Headers:    headers,
-			Body:       fmt.Sprintf(`{"error": "加载配置文件失败: %v"}`, err),
+			Body:       errorBody("加载配置文件失败: %v", err),
 		}, nil
 	}
 
@@ -175,14 +291,18 @@ func HandleRequest(ctx context.Context, request AliyunFunctionRequest) (AliyunFu
 	case "test":
 		result = runTestMode()
 	case "debug":
-		result = runDebugModeForFunction(config.UrlFv, config.Cookie)
+		result = runDebugModeForFunction(ctx, *config, categoryURL(config.DefaultCategory()), config.Cookie)
 	case "normal", "":
-		result = runNormalModeForFunction(config.UrlFv, config.Cookie)
+		// 与页面入口使用同样的数据，默认输出JSON
+		if request.Format == "" {
+			request.Format = FormatJSON
+		}
+		return HandleHttpRequestWithHtml(ctx, request)
 	default:
 		return AliyunFunctionResponse{
 			StatusCode: 400,
 			Headers:    headers,
-			Body:       `{"error": "无效的模式，支持的模式：normal, debug, test"}`,
+			Body:       errorBody("无效的模式，支持的模式：normal, debug, test"),
 		}, nil
 	}
 
@@ -190,7 +310,7 @@ func HandleRequest(ctx context.Context, request AliyunFunctionRequest) (AliyunFu
 		return AliyunFunctionResponse{
 			StatusCode: 500,
 			Headers:    headers,
-			Body:       fmt.Sprintf(`{"error": "执行失败: %v"}`, err),
+			Body:       errorBody("执行失败: %v", err),
 		}, nil
 	}
 
@@ -200,7 +320,7 @@ func HandleRequest(ctx context.Context, request AliyunFunctionRequest) (AliyunFu
 		return AliyunFunctionResponse{
 			StatusCode: 500,
 			Headers:    headers,
-			Body:       `{"error": "序列化结果失败"}`,
+			Body:       errorBody("序列化结果失败"),
 		}, nil
 	}
 
@@ -211,77 +331,105 @@ func HandleRequest(ctx context.Context, request AliyunFunctionRequest) (AliyunFu
 	}, nil
 }
 
-func fetchAllProductTypesConcurrently(config Config) PageData {
+// fetchDeadlineReserve 为渲染和返回响应预留的时间，分类抓取需在此之前结束
+const fetchDeadlineReserve = 2 * time.Second
+
+// categoryContext 根据调用方（如阿里云函数调用）的截止时间派生分类抓取的截止时间，
+// 保证超时的分类被放弃后仍有时间返回部分结果，而不是被平台直接终止
+func categoryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	reserve := fetchDeadlineReserve
+	// 剩余时间很短时按比例预留
+	if remaining := time.Until(deadline); remaining < 2*reserve {
+		reserve = remaining / 4
+	}
+	return context.WithDeadline(ctx, deadline.Add(-reserve))
+}
+
+// fetchStoresConcurrently 同时抓取多个门店的所有启用分类，stores 为空时按 cookie 中的门店抓取
+func fetchStoresConcurrently(ctx context.Context, config Config, stores []StoreConfig) PageData {
 
 	var wg sync.WaitGroup
 
-	// 定义一个通用的获取函数
-	fetch := func(url string, result *[]Product) {
-		defer wg.Done()
-		products, _ := fetchAllProductInfo(url, config.Cookie) // 这里简化处理，实际应考虑错误处理
-		if products != nil {
-			converted := make([]Product, len(products))
-			for i, p := range products {
-				if p != nil {
-					converted[i] = *p
-				}
-			}
-			*result = converted
-		} else {
-			*result = nil
-		}
-	}
+	ctx, cancel := categoryContext(ctx)
+	defer cancel()
 
-	// 为每种类型启动一个goroutine
-	wg.Add(5)
-	products_fv := make([]Product, 0)
-	products_lv := make([]Product, 0)
-	products_rv := make([]Product, 0)
-	products_m := make([]Product, 0)
-	products_c := make([]Product, 0)
-	go fetch(config.UrlFv, &products_fv) // 瓜果蔬菜类
-	go fetch(config.UrlLv, &products_lv) // 叶菜类
-	go fetch(config.UrlRv, &products_rv) // 根茎类
-	go fetch(config.UrlM, &products_m)   // 菌菇类
-	go fetch(config.UrlC, &products_c)   // 调味菜
+	// 为每个门店的每个启用分类启动一个goroutine
+	categories := storeCategories(config, stores)
+	var results PageData
+	results.Categories = make([]Category, len(categories))
+	for i, c := range categories {
+		wg.Add(1)
+		go func(i int, c CategoryConfig) {
+			defer wg.Done()
+			results.Categories[i] = productCache.Get(ctx, config, c)
+		}(i, c)
+	}
 
 	// 等待所有请求完成
 	wg.Wait()
 
-	var results = PageData{
-		Categories: []Category{
-			{
-
-				ID:       "fruit-vegetable",
-				Name:     "瓜果花菜类",
-				Products: products_fv,
-			},
-			{
-				ID:       "leaf-vegetable",
-				Name:     "叶菜类",
-				Products: products_lv,
-			},
-			{
-				ID:       "root-vegetable",
-				Name:     "根茎类",
-				Products: products_rv,
-			},
-			{
-				ID:       "mushroom",
-				Name:     "菌菇类",
-				Products: products_m,
-			},
-			{
-				ID:       "condiment",
-				Name:     "调味菜",
-				Products: products_c,
-			},
-		},
-	}
+	results.Errors = collectCategoryErrors(results.Categories)
+	results.PriceUnit, _ = parsePriceUnit(config.PriceUnit)
+	results.Stores = publicStores(config.Stores)
+	results.Store = storeParam(config, stores)
 
 	return results
 }
 
+// fetchCategory 抓取单个分类，失败时记录错误信息而不是返回空分类
+func fetchCategory(ctx context.Context, config Config, c CategoryConfig) Category {
+	start := time.Now()
+	products, err := fetchCategoryProducts(ctx, config, c)
+
+	category := Category{
+		ID:         c.ID,
+		Name:       c.Name,
+		Adapter:    adapterName(c),
+		Store:      c.Store,
+		StoreName:  storeName(config, c.Store),
+		Products:   make([]Product, 0, len(products)),
+		Status:     CategoryStatusOK,
+		DurationMs: time.Since(start).Milliseconds(),
+		FetchedAt:  start,
+	}
+	if err != nil {
+		fmt.Printf("获取分类 %s 失败: %v\n", c.Name, err)
+		category.Status = CategoryStatusFailed
+		category.Error = err.Error()
+		return category
+	}
+
+	category.Products = append(category.Products, products...)
+
+	// 保存价格历史
+	recordHistory(config, category)
+	return category
+}
+
+// collectCategoryErrors 汇总抓取失败或使用旧数据的分类
+func collectCategoryErrors(categories []Category) []CategoryError {
+	errs := make([]CategoryError, 0)
+	for _, c := range categories {
+		if c.Status == CategoryStatusOK {
+			continue
+		}
+		errs = append(errs, CategoryError{
+			CategoryID:   c.ID,
+			CategoryName: c.Name,
+			Store:        c.Store,
+			StoreName:    c.StoreName,
+			Status:       c.Status,
+			Message:      c.Error,
+			FetchedAt:    c.FetchedAt,
+		})
+	}
+	return errs
+}
+
 func HandleHttpRequestWithHtml(ctx context.Context, request AliyunFunctionRequest) (AliyunFunctionResponse, error) {
 	fmt.Println("阿里云函数环境，执行HandleRequest函数")
 	// 设置响应头
@@ -290,438 +438,151 @@ func HandleHttpRequestWithHtml(ctx context.Context, request AliyunFunctionReques
 		"Access-Control-Allow-Origin":  "*",
 		"Access-Control-Allow-Methods": "GET, POST, OPTIONS",
 		"Access-Control-Allow-Headers": "Content-Type",
+		"Vary":                         "Accept",
 	}
 
-	// 根据模式执行不同操作
-
-	config, err := LoadConfig("config.json")
+	// 确定输出格式
+	format, err := negotiateFormat(request.Format, request.Accept)
 	if err != nil {
+		status := 406
+		if request.Format != "" {
+			status = 400
+		}
+		headers["Content-Type"] = "application/json; charset=utf-8"
 		return AliyunFunctionResponse{
-			StatusCode: 500,
+			StatusCode: status,
 			Headers:    headers,
-			Body:       fmt.Sprintf(`{"error": "加载配置文件失败: %v"}`, err),
+			Body:       errorBody("%v", err),
 		}, nil
 	}
+	if format != FormatHTML {
+		// 错误信息为JSON，CSV成功时再改为 text/csv
+		headers["Content-Type"] = "application/json; charset=utf-8"
+	}
 
-	data := fetchAllProductTypesConcurrently(*config)
-
-	tmpl := template.New("product_list.html").Option("missingkey=error")
-	tmpl, err = tmpl.ParseFiles("product_list.html")
+	config, err := request.loadConfig()
 	if err != nil {
 		return AliyunFunctionResponse{
 			StatusCode: 500,
 			Headers:    headers,
-			Body:       `{"error": "解析模板失败"}`,
+			Body:       errorBody("加载配置文件失败: %v", err),
 		}, nil
 	}
-	var buf bytes.Buffer
 
-	if tmpl.Execute(&buf, data); err != nil {
+	unit, err := priceUnitFor(*config, request.Unit)
+	if err != nil {
 		return AliyunFunctionResponse{
-			StatusCode: 500,
+			StatusCode: 400,
 			Headers:    headers,
-			Body:       `{"error": "执行模板失败"}`,
+			Body:       errorBody("%v", err),
 		}, nil
 	}
-	htmlStr := buf.String()
-
-	return AliyunFunctionResponse{
-		StatusCode: 200,
-		Headers:    headers,
-		Body:       htmlStr,
-	}, nil
-
-}
-
-// fetchProductInfo 获取商品信息
-func fetchProductInfo(url, cookie string) (*Product, error) {
-	// 创建HTTP客户端
-	client := &http.Client{}
-
-	// 创建请求
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %v", err)
-	}
-
-	// 设置cookie
-	req.Header.Set("Cookie", cookie)
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
-
-	// 发送请求
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("发送请求失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	// 读取响应内容
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %v", err)
-	}
-
-	// 解析HTML内容，获取所有商品
-	products, err := parseHTML(string(body))
-	if err != nil {
-		return nil, fmt.Errorf("解析HTML失败: %v", err)
-	}
-
-	// 如果没有找到商品，返回空商品
-	if len(products) == 0 {
-		return &Product{}, nil
-	}
-
-	// 返回第一个商品（向后兼容）
-	return products[0], nil
-}
-
-// fetchAllProductInfo 获取所有商品信息
-func fetchAllProductInfo(url, cookie string) ([]*Product, error) {
-	// 加载配置
-	config, err := LoadConfig("config.json")
-	if err != nil {
-		// 如果配置文件不存在，使用默认配置
-		config = GetConfig()
-	}
-
-	// 创建HTTP客户端
-	client := &http.Client{
-		Timeout: time.Duration(config.Timeout) * time.Second,
-	}
-
-	// 创建请求
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %v", err)
-	}
-
-	// 设置cookie和user-agent
-	req.Header.Set("Cookie", cookie)
-	req.Header.Set("User-Agent", config.UserAgent)
-
-	// 发送请求
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("发送请求失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	// 读取响应内容
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %v", err)
-	}
 
-	// 解析HTML内容，获取所有商品
-	products, err := parseHTML(string(body))
+	stores, err := config.selectStores(request.Store)
 	if err != nil {
-		return nil, fmt.Errorf("解析HTML失败: %v", err)
-	}
-
-	return products, nil
-}
-
-// parseHTML 解析HTML内容，提取所有商品信息
-func parseHTML(htmlContent string) ([]*Product, error) {
-	var products []*Product
-
-	// 解析HTML
-	doc, err := html.Parse(strings.NewReader(htmlContent))
-	if err != nil {
-		return nil, err
-	}
-
-	// 查找包含商品的容器 div.index_picAD
-	productContainer := findNodeByClassname(doc, "div", "index_picAD")
-	if productContainer == nil {
-		return products, fmt.Errorf("未找到商品容器 index_picAD")
-	}
-
-	// 遍历容器下的所有直接子div，每个都是一个商品
-	for child := productContainer.FirstChild; child != nil; child = child.NextSibling {
-		if child.Type == html.ElementNode && child.Data == "div" {
-			product := parseProductFromDiv(child)
-			if product != nil && (product.Name != "" || product.Price > 0) {
-				products = append(products, product)
-			}
-		}
-	}
-
-	return products, nil
-}
-
-// findNodeByClassname 根据类名查找节点
-func findNodeByClassname(n *html.Node, tag, className string) *html.Node {
-	if n.Type == html.ElementNode && n.Data == tag {
-		for _, attr := range n.Attr {
-			if attr.Key == "class" && strings.Contains(attr.Val, className) {
-				return n
-			}
-		}
-	}
-
-	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		if result := findNodeByClassname(c, tag, className); result != nil {
-			return result
-		}
-	}
-
-	return nil
-}
-
-// parseProductFromDiv 从单个商品div中解析商品信息
-func parseProductFromDiv(productDiv *html.Node) *Product {
-	product := &Product{}
-
-	product.ID = extractAttr(productDiv, "a", "href")
-	// 提取商品名称 - 常见的选择器
-	product.Name = extractTextFromNode(productDiv, "h3", "", "")
-	if product.Name == "" {
-		product.Name = extractTextFromNode(productDiv, "h2", "", "")
-	}
-	if product.Name == "" {
-		product.Name = extractTextFromNode(productDiv, "h4", "", "")
-	}
-	if product.Name == "" {
-		product.Name = extractTextFromNode(productDiv, "span", "", "")
-	}
-	if product.Name == "" {
-		product.Name = extractTextFromNode(productDiv, "div", "", "")
-	}
-
-	// 提取价格 - 查找包含价格的元素
-	priceText := extractTextFromNode(productDiv, "span", "class", "price")
-	if priceText == "" {
-		priceText = extractTextFromNode(productDiv, "div", "class", "price")
-	}
-	if priceText == "" {
-		// 查找所有span和div，寻找包含数字和元/￥的文本
-		priceText = findPriceText(productDiv)
-	}
-
-	// 清理价格文本，提取数字
-	if priceText != "" {
-		priceText = cleanPriceText(priceText)
-		product.Price, _ = strconv.ParseFloat(priceText, 64)
-	}
-
-	// 提取规格 - 查找包含重量信息的元素
-	product.Spec = extractTextFromNode(productDiv, "span", "class", "spec")
-	if product.Spec == "" {
-		product.Spec = extractTextFromNode(productDiv, "div", "class", "spec")
-	}
-	if product.Spec == "" {
-		product.Spec = extractTextFromNode(productDiv, "span", "style", "font-size:11px;")
-	}
-	if product.Spec == "" {
-		// 查找包含重量单位的文本
-		product.Spec = findSpecText(productDiv)
-	}
-	if product.Spec == "" {
-		// 从商品名称中提取规格信息
-		product.Spec = extractSpecFromName(product.Name)
+		return AliyunFunctionResponse{
+			StatusCode: 400,
+			Headers:    headers,
+			Body:       errorBody("%v", err),
+		}, nil
 	}
 
-	// 判断是否为包装商品
-	product.IsPackaged = isPackagedProduct(product.Spec)
-
-	// 根据商品类型计算价格
-	if product.IsPackaged {
-		// 包装商品直接显示包装价格
-		product.PricePerJin = product.Price
-		product.Unit = getPackagedUnit(product.Spec)
-	} else {
-		// 重量商品计算每斤价格
-		product.PricePerJin = calculatePricePerJin(product.Price, product.Spec)
-		product.Unit = "元/斤"
+	items := parseShoppingList(*config, request.List)
+	if len(items) > maxShoppingItems {
+		return AliyunFunctionResponse{
+			StatusCode: 400,
+			Headers:    headers,
+			Body:       errorBody("购物清单最多%d项", maxShoppingItems),
+		}, nil
 	}
 
-	return product
-}
-
-func extractAttr(n *html.Node, tag, attr string) string {
-	var result string
-
-	var traverse func(*html.Node)
-	traverse = func(node *html.Node) {
-		if node.Type == html.ElementNode && node.Data == tag {
-			// 如果指定了属性，检查属性值
-			if attr != "" {
-				for _, a := range node.Attr {
-					if a.Key == attr {
-						result = a.Val
-						return
-					}
-				}
-			} else if attr == "" {
-				// 如果没有指定属性，直接获取第一个匹配的标签
-				if result == "" {
-					result = ""
-				}
-			}
-		}
-
-		for c := node.FirstChild; c != nil; c = c.NextSibling {
-			traverse(c)
-		}
+	data := fetchStoresConcurrently(ctx, *config, stores)
+	if request.serve {
+		notifyAlertsAsync(*config, data.Categories)
 	}
-
-	traverse(n)
-	return strings.TrimSpace(result)
-}
-
-// extractText 从HTML中提取指定标签的文本内容
-func extractText(n *html.Node, tag, attr, value string) string {
-	var result string
-
-	var traverse func(*html.Node)
-	traverse = func(node *html.Node) {
-		if node.Type == html.ElementNode && node.Data == tag {
-			// 如果指定了属性，检查属性值
-			if attr != "" && value != "" {
-				for _, a := range node.Attr {
-					if a.Key == attr && a.Val == value {
-						result = getTextContent(node)
-						return
-					}
-				}
-			} else if attr == "" {
-				// 如果没有指定属性，直接获取第一个匹配的标签
-				if result == "" {
-					result = getTextContent(node)
-				}
-			}
-		}
-
-		for c := node.FirstChild; c != nil; c = c.NextSibling {
-			traverse(c)
-		}
+	data.PriceUnit = unit
+	data.Diff = todayDiff(*config, data)
+	if report := comparePrices(data, ""); len(report.Sources) > 1 {
+		data.Comparison = &report
 	}
-
-	traverse(n)
-	return strings.TrimSpace(result)
-}
-
-// getTextContent 获取节点的文本内容
-func getTextContent(n *html.Node) string {
-	var result string
-
-	var traverse func(*html.Node)
-	traverse = func(node *html.Node) {
-		if node.Type == html.TextNode {
-			result += node.Data
-		}
-
-		for c := node.FirstChild; c != nil; c = c.NextSibling {
-			traverse(c)
-		}
+	if len(items) > 0 {
+		result := calculateShoppingList(*config, data, items)
+		data.Shopping = &result
+		data.List = request.List
 	}
-
-	traverse(n)
-	return result
-}
-
-// extractTextFromNode 从指定节点中提取文本内容
-func extractTextFromNode(n *html.Node, tag, attr, value string) string {
-	var result string
-
-	var traverse func(*html.Node)
-	traverse = func(node *html.Node) {
-		if node.Type == html.ElementNode && node.Data == tag {
-			// 如果指定了属性，检查属性值
-			if attr != "" && value != "" {
-				for _, a := range node.Attr {
-					if a.Key == attr && strings.Contains(a.Val, value) {
-						result = getTextContent(node)
-						return
-					}
-				}
-			} else if attr == "" {
-				// 如果没有指定属性，直接获取第一个匹配的标签
-				if result == "" {
-					result = getTextContent(node)
-				}
-			}
+	if request.Watchlist != "" {
+		if err := config.Watchlists.checkWatchlistID(request.Watchlist); err != nil {
+			return AliyunFunctionResponse{
+				StatusCode: 400,
+				Headers:    headers,
+				Body:       errorBody("%v", err),
+			}, nil
 		}
-
-		for c := node.FirstChild; c != nil; c = c.NextSibling {
-			traverse(c)
+		watchlist, err := getWatchlist(config.Watchlists, request.Watchlist)
+		if err != nil {
+			return AliyunFunctionResponse{
+				StatusCode: 500,
+				Headers:    headers,
+				Body:       errorBody("%v", err),
+			}, nil
 		}
-	}
-
-	traverse(n)
-	return strings.TrimSpace(result)
-}
-
-// findPriceText 在节点中查找价格文本
-func findPriceText(n *html.Node) string {
-	var priceTexts []string
-
-	var traverse func(*html.Node)
-	traverse = func(node *html.Node) {
-		if node.Type == html.TextNode {
-			text := strings.TrimSpace(node.Data)
-			// 查找包含价格相关字符的文本
-			if strings.Contains(text, "元") || strings.Contains(text, "￥") || strings.Contains(text, "$") {
-				// 检查是否包含数字
-				if regexp.MustCompile(`\d+(\.\d+)?`).MatchString(text) {
-					priceTexts = append(priceTexts, text)
-				}
-			}
+		data.Watchlist = &watchlist
+	}
+	headers["Age"] = strconv.FormatInt(data.maxCacheAge(), 10)
+
+	switch format {
+	case FormatJSON:
+		body, err := json.Marshal(newProductListResponse(data, time.Now()))
+		if err != nil {
+			return AliyunFunctionResponse{
+				StatusCode: 500,
+				Headers:    headers,
+				Body:       errorBody("序列化结果失败"),
+			}, nil
 		}
-
-		for c := node.FirstChild; c != nil; c = c.NextSibling {
-			traverse(c)
-		}
-	}
-
-	traverse(n)
-
-	// 如果找到多个价格文本，选择第一个包含￥的
-	for _, text := range priceTexts {
-		if strings.Contains(text, "￥") {
-			return text
+		return AliyunFunctionResponse{
+			StatusCode: 200,
+			Headers:    headers,
+			Body:       string(body),
+		}, nil
+	case FormatCSV:
+		var body strings.Builder
+		if err := writeProductsCSV(&body, data); err != nil {
+			return AliyunFunctionResponse{
+				StatusCode: 500,
+				Headers:    headers,
+				Body:       errorBody("生成CSV失败: %v", err),
+			}, nil
 		}
+		headers["Content-Type"] = "text/csv; charset=utf-8"
+		headers["Content-Disposition"] = `inline; filename="products.csv"`
+		return AliyunFunctionResponse{
+			StatusCode: 200,
+			Headers:    headers,
+			Body:       body.String(),
+		}, nil
 	}
 
-	// 如果没有￥，返回第一个
-	if len(priceTexts) > 0 {
-		return priceTexts[0]
+	data.StaticURL = config.StaticURL
+	if data.StaticURL == "" && request.serve {
+		data.StaticURL = "/static/"
 	}
-
-	return ""
-}
-
-// findSpecText 在节点中查找规格文本
-func findSpecText(n *html.Node) string {
-	var specText string
-
-	var traverse func(*html.Node)
-	traverse = func(node *html.Node) {
-		if node.Type == html.TextNode {
-			text := strings.TrimSpace(node.Data)
-			// 查找包含重量单位的文本
-			weightUnits := []string{"斤", "kg", "千克", "g", "克", "两", "磅"}
-			for _, unit := range weightUnits {
-				if strings.Contains(text, unit) {
-					// 检查是否包含数字
-					if regexp.MustCompile(`\d+(\.\d+)?`).MatchString(text) {
-						if specText == "" {
-							specText = text
-						}
-					}
-				}
-			}
-		}
-
-		for c := node.FirstChild; c != nil; c = c.NextSibling {
-			traverse(c)
-		}
+	data.WatchlistSync = request.serve
+	htmlStr, err := renderProductList(config.TemplateDir, data)
+	if err != nil {
+		fmt.Println(err)
+		return AliyunFunctionResponse{
+			StatusCode: 500,
+			Headers:    headers,
+			Body:       errorBody("渲染页面失败"),
+		}, nil
 	}
+	return AliyunFunctionResponse{
+		StatusCode: 200,
+		Headers:    headers,
+		Body:       htmlStr,
+	}, nil
 
-	traverse(n)
-	return specText
 }
 
 // extractSpecFromName 从商品名称中提取规格信息
@@ -774,6 +635,44 @@ func cleanPriceText(text string) string {
 	return "0"
 }
 
+// updatePricePerJin 根据规格计算售价、原价和会员价的每斤价格，包装商品使用包装价格。
+// 原价不高于售价时不是促销，清空原价
+func (p *Product) updatePricePerJin() {
+	if p.OriginalPrice <= p.Price {
+		p.OriginalPrice = 0
+	}
+
+	p.IsPackaged = isPackagedProduct(p.Spec)
+	perJin := func(price float64) float64 {
+		if p.IsPackaged {
+			return price
+		}
+		return calculatePricePerJin(price, p.Spec)
+	}
+	if p.IsPackaged {
+		// 包装商品直接显示包装价格
+		p.Unit = getPackagedUnit(p.Spec)
+	} else {
+		// 重量商品计算每斤价格
+		p.Unit = unitPerJin
+	}
+	p.PricePerJin = perJin(p.Price)
+	p.OriginalPricePerJin = perJin(p.OriginalPrice)
+	p.MemberPricePerJin = perJin(p.MemberPrice)
+	p.PricePerGram = 0
+	if !p.IsPackaged {
+		p.PricePerGram = pricePerGram(p.PricePerJin)
+	}
+}
+
+// DiscountPerJin 促销时每斤比原价便宜的金额，没有原价时为0
+func (p Product) DiscountPerJin() float64 {
+	if p.OriginalPricePerJin <= p.PricePerJin {
+		return 0
+	}
+	return p.OriginalPricePerJin - p.PricePerJin
+}
+
 // calculatePricePerJin 根据价格和规格计算每斤价格
 func calculatePricePerJin(price float64, spec string) float64 {
 	if price <= 0 {
@@ -790,70 +689,19 @@ func calculatePricePerJin(price float64, spec string) float64 {
 	return price / weight
 }
 
-// parseWeightFromSpec 从规格中解析重量（斤）
+// parseWeightFromSpec 从规格中解析重量（斤），范围取中值，计件规格按估算重量
 func parseWeightFromSpec(spec string) float64 {
-	if spec == "" {
-		return 0
-	}
-
-	// 转换为小写便于匹配
-	specLower := strings.ToLower(spec)
-
-	// 匹配常见的重量单位
-	patterns := []struct {
-		regex *regexp.Regexp
-		unit  float64
-	}{
-		{regexp.MustCompile(`(\d+(?:\.\d+)?)\s*斤`), 1.0},   // 斤
-		{regexp.MustCompile(`(\d+(?:\.\d+)?)\s*kg`), 2.0},  // 公斤
-		{regexp.MustCompile(`(\d+(?:\.\d+)?)\s*千克`), 2.0},  // 千克
-		{regexp.MustCompile(`(\d+(?:\.\d+)?)\s*g`), 0.002}, // 克
-		{regexp.MustCompile(`(\d+(?:\.\d+)?)\s*克`), 0.002}, // 克
-		{regexp.MustCompile(`(\d+(?:\.\d+)?)\s*两`), 0.1},   // 两
-		{regexp.MustCompile(`(\d+(?:\.\d+)?)\s*磅`), 0.907}, // 磅
-	}
-
-	for _, pattern := range patterns {
-		matches := pattern.regex.FindStringSubmatch(specLower)
-		if len(matches) > 1 {
-			if weight, err := strconv.ParseFloat(matches[1], 64); err == nil {
-				return weight * pattern.unit
-			}
-		}
-	}
-
-	// 处理包装单位 - 估算重量
-	packPatterns := []struct {
-		regex           *regexp.Regexp
-		estimatedWeight float64 // 估算重量（斤）
-	}{
-		{regexp.MustCompile(`(\d+(?:\.\d+)?)\s*盒`), 0.5}, // 盒装估算0.5斤
-		{regexp.MustCompile(`(\d+(?:\.\d+)?)\s*袋`), 1.0}, // 袋装估算1斤
-		{regexp.MustCompile(`(\d+(?:\.\d+)?)\s*包`), 1.0}, // 包装估算1斤
-		{regexp.MustCompile(`(\d+(?:\.\d+)?)\s*个`), 0.2}, // 个装估算0.2斤
-		{regexp.MustCompile(`(\d+(?:\.\d+)?)\s*只`), 0.3}, // 只装估算0.3斤
-		{regexp.MustCompile(`(\d+(?:\.\d+)?)\s*束`), 0.5}, // 束装估算0.5斤
-		{regexp.MustCompile(`(\d+(?:\.\d+)?)\s*把`), 0.3}, // 把装估算0.3斤
-		{regexp.MustCompile(`(\d+(?:\.\d+)?)\s*根`), 0.1}, // 根装估算0.1斤
-	}
-
-	for _, pattern := range packPatterns {
-		matches := pattern.regex.FindStringSubmatch(specLower)
-		if len(matches) > 1 {
-			if count, err := strconv.ParseFloat(matches[1], 64); err == nil {
-				return count * pattern.estimatedWeight
-			}
-		}
-	}
-
-	return 0
+	return parseSpec(spec).Weight()
 }
 
-// isPackagedProduct 判断是否为包装商品
+// isPackagedProduct 判断是否为包装商品，标明了重量的包装（如 500g/袋）按重量计算
 func isPackagedProduct(spec string) bool {
 	if spec == "" {
 		return true
 	}
+	if info := parseSpec(spec); info.HasWeight() && !info.Estimated {
+		return false
+	}
 
 	// 包装单位模式
 	packPatterns := []string{"盒", "袋", "包", "筐", "箱", "个", "只", "束", "把", "根"}
@@ -914,7 +762,7 @@ func runTestMode() map[string]interface{} {
 }
 
 // runDebugModeForFunction 运行调试模式（返回JSON结果）
-func runDebugModeForFunction(url, cookie string) map[string]interface{} {
+func runDebugModeForFunction(ctx context.Context, config Config, url, cookie string) map[string]interface{} {
 	result := map[string]interface{}{
 		"mode":    "debug",
 		"url":     url,
@@ -923,44 +771,32 @@ func runDebugModeForFunction(url, cookie string) map[string]interface{} {
 
 	// 如果提供了URL和Cookie，执行实际的调试
 	if url != "" && cookie != "" {
+		config.Cookie = cookie
+		adapter, _ := getAdapter(DefaultAdapter)
+		result["adapter"] = adapter.Name()
+		if missing := missingCookies(adapter, cookie); len(missing) > 0 {
+			result["missing_cookies"] = missing
+		}
+
 		// 获取网页内容
-		client := &http.Client{
-			Timeout: 30 * time.Second,
+		page, err := adapter.Fetch(ctx, config, url)
+		// 适配器失败时可能返回空结果
+		if page != nil {
+			result["attempts"] = page.Attempts
 		}
-		req, err := http.NewRequest("GET", url, nil)
-		if err == nil {
-			req.Header.Set("Cookie", cookie)
-			req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
-
-			resp, err := client.Do(req)
-			if err == nil {
-				defer resp.Body.Close()
-				body, err := io.ReadAll(resp.Body)
-				if err == nil {
-					htmlContent := string(body)
-					result["html_length"] = len(htmlContent)
-					result["status"] = "success"
-
-					// 解析HTML
-					doc, err := html.Parse(strings.NewReader(htmlContent))
-					if err == nil {
-						// 查找index_picAD容器
-						productContainer := findNodeByClassname(doc, "div", "index_picAD")
-						if productContainer != nil {
-							result["container_found"] = true
-							// 计算子div数量
-							childDivCount := 0
-							for child := productContainer.FirstChild; child != nil; child = child.NextSibling {
-								if child.Type == html.ElementNode && child.Data == "div" {
-									childDivCount++
-								}
-							}
-							result["product_count"] = childDivCount
-						} else {
-							result["container_found"] = false
-						}
-					}
-				}
+		if err != nil {
+			result["status"] = "failed"
+			result["error"] = err.Error()
+		} else {
+			result["html_length"] = len(page.Body)
+			result["status"] = "success"
+
+			// 解析商品
+			products, err := adapter.Parse(ctx, config, page.Body)
+			if err != nil {
+				result["parse_error"] = err.Error()
+			} else {
+				result["product_count"] = len(products)
 			}
 		}
 	}
@@ -968,30 +804,6 @@ func runDebugModeForFunction(url, cookie string) map[string]interface{} {
 	return result
 }
 
-// runNormalModeForFunction 运行正常模式（返回JSON结果）
-func runNormalModeForFunction(url, cookie string) map[string]interface{} {
-	fmt.Println("运行正常模式")
-	result := map[string]interface{}{
-		"mode":    "normal",
-		"url":     url,
-		"message": "商品信息获取完成",
-	}
-
-	// 获取所有商品信息
-	products, err := fetchAllProductInfo(url, cookie)
-	if err != nil {
-		result["error"] = err.Error()
-		result["status"] = "failed"
-		return result
-	}
-
-	result["status"] = "success"
-	result["total_products"] = len(products)
-	result["products"] = products
-
-	return result
-}
-
 // runDebugMode 运行调试模式
 func runDebugMode() {
 	fmt.Println("=== 调试模式 ===")
@@ -1004,118 +816,51 @@ func runDebugMode() {
 		config = GetConfig()
 	}
 
-	fmt.Printf("目标URL: %s\n", config.UrlFv)
-	fmt.Printf("超时设置: %d秒\n", config.Timeout)
-	fmt.Printf("重试次数: %d次\n", config.RetryCount)
-	fmt.Println("正在获取网页内容...")
-
-	// 获取网页内容
-	client := &http.Client{
-		Timeout: time.Duration(config.Timeout) * time.Second,
-	}
-	req, err := http.NewRequest("GET", config.UrlFv, nil)
+	category := config.DefaultCategory()
+	adapter, err := getAdapter(category.Adapter)
 	if err != nil {
-		fmt.Printf("创建请求失败: %v\n", err)
+		fmt.Println(err)
 		return
 	}
-
-	req.Header.Set("Cookie", config.Cookie)
-	req.Header.Set("User-Agent", config.UserAgent)
-
-	resp, err := client.Do(req)
+	url, err := adapter.CategoryURL(category)
 	if err != nil {
-		fmt.Printf("发送请求失败: %v\n", err)
+		fmt.Println(err)
 		return
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Printf("读取响应失败: %v\n", err)
-		return
+	fmt.Printf("网站适配器: %s（%s）\n", adapter.Name(), adapter.Description())
+	fmt.Printf("目标URL: %s\n", url)
+	fmt.Printf("超时设置: %d秒\n", config.Timeout)
+	fmt.Printf("重试次数: %d次\n", config.RetryCount)
+	for _, c := range missingCookies(adapter, config.Cookie) {
+		fmt.Printf("⚠️  Cookie中缺少 %s：%s\n", c.Name, c.Description)
 	}
+	fmt.Println("正在获取网页内容...")
 
-	htmlContent := string(body)
-	fmt.Printf("网页内容长度: %d 字符\n", len(htmlContent))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// 解析HTML
-	doc, err := html.Parse(strings.NewReader(htmlContent))
-	if err != nil {
-		fmt.Printf("解析HTML失败: %v\n", err)
-		return
+	page, err := adapter.Fetch(ctx, *config, url)
+	fmt.Println("请求详情:")
+	if page != nil {
+		printFetchAttempts(page.Attempts)
 	}
-
-	// 查找index_picAD容器
-	fmt.Println("\n查找 index_picAD 容器...")
-	productContainer := findNodeByClassname(doc, "div", "index_picAD")
-	if productContainer == nil {
-		fmt.Println("❌ 未找到 index_picAD 容器")
-		fmt.Println("\n搜索所有包含 'pic' 的class...")
-		findSimilarClasses(doc, "pic")
+	if err != nil {
+		fmt.Printf("获取网页失败: %v\n", err)
 		return
 	}
 
-	fmt.Println("✅ 找到 index_picAD 容器")
-
-	// 计算子div数量
-	childDivCount := 0
-	for child := productContainer.FirstChild; child != nil; child = child.NextSibling {
-		if child.Type == html.ElementNode && child.Data == "div" {
-			childDivCount++
-		}
-	}
-
-	fmt.Printf("容器下有 %d 个子div\n", childDivCount)
-
-	// 解析前几个商品用于调试
-	fmt.Println("\n解析前3个商品div:")
-	count := 0
-	for child := productContainer.FirstChild; child != nil && count < 3; child = child.NextSibling {
-		if child.Type == html.ElementNode && child.Data == "div" {
-			count++
-			fmt.Printf("\n--- 商品div %d ---\n", count)
-
-			// 显示div的属性
-			for _, attr := range child.Attr {
-				fmt.Printf("属性: %s = %s\n", attr.Key, attr.Val)
-			}
-
-			// 解析商品信息
-			product := parseProductFromDiv(child)
-			fmt.Printf("解析结果:\n")
-			fmt.Printf("  名称: '%s'\n", product.Name)
-			fmt.Printf("  价格: %.2f元\n", product.Price)
-			fmt.Printf("  规格: '%s'\n", product.Spec)
-
-			if product.IsPackaged {
-				fmt.Printf("  包装价格: %.2f%s\n", product.PricePerJin, product.Unit)
-			} else {
-				fmt.Printf("  每斤价格: %.2f%s\n", product.PricePerJin, product.Unit)
-			}
+	htmlContent := page.Body
+	fmt.Printf("网页内容长度: %d 字符\n", len(htmlContent))
 
-			// 显示原始文本内容
-			rawText := getTextContent(child)
-			fmt.Printf("  原始文本: '%s'\n", strings.TrimSpace(rawText))
-		}
+	if debugger, ok := adapter.(adapterDebugger); ok {
+		debugger.Debug(*config, htmlContent)
+		return
 	}
-}
-
-// findSimilarClasses 查找相似的class名
-func findSimilarClasses(n *html.Node, keyword string) {
-	var traverse func(*html.Node)
-	traverse = func(node *html.Node) {
-		if node.Type == html.ElementNode {
-			for _, attr := range node.Attr {
-				if attr.Key == "class" && strings.Contains(strings.ToLower(attr.Val), strings.ToLower(keyword)) {
-					fmt.Printf("找到相似class: %s (标签: %s)\n", attr.Val, node.Data)
-				}
-			}
-		}
-
-		for c := node.FirstChild; c != nil; c = c.NextSibling {
-			traverse(c)
-		}
+	products, err := adapter.Parse(ctx, *config, htmlContent)
+	if err != nil {
+		fmt.Printf("解析页面失败: %v\n", err)
+		return
 	}
-
-	traverse(n)
+	fmt.Printf("解析到 %d 个商品\n", len(products))
 }
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 通知渠道类型
+const (
+	NotifierWebhook  = "webhook"  // 通用webhook，POST JSON
+	NotifierWeCom    = "wecom"    // 企业微信群机器人
+	NotifierDingTalk = "dingtalk" // 钉钉群机器人
+	NotifierFeishu   = "feishu"   // 飞书群机器人
+	NotifierEmail    = "email"    // SMTP邮件
+)
+
+// Notifier 提醒通知渠道
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, alerts []Alert) error
+}
+
+// newNotifier 根据配置创建通知渠道
+func newNotifier(cfg NotifierConfig) (Notifier, error) {
+	switch cfg.Type {
+	case NotifierWebhook:
+		return &webhookNotifier{url: cfg.URL}, nil
+	case NotifierWeCom:
+		return &robotNotifier{name: NotifierWeCom, url: cfg.URL}, nil
+	case NotifierDingTalk:
+		return &robotNotifier{name: NotifierDingTalk, url: cfg.URL, secret: cfg.Secret}, nil
+	case NotifierFeishu:
+		return &robotNotifier{name: NotifierFeishu, url: cfg.URL, secret: cfg.Secret}, nil
+	case NotifierEmail:
+		return &emailNotifier{cfg: cfg}, nil
+	default:
+		return nil, fmt.Errorf("不支持的通知渠道: %s", cfg.Type)
+	}
+}
+
+// validateNotifier 检查通知渠道配置
+func validateNotifier(cfg NotifierConfig) error {
+	switch cfg.Type {
+	case NotifierWebhook, NotifierWeCom, NotifierDingTalk, NotifierFeishu:
+		if cfg.URL == "" {
+			return fmt.Errorf("通知渠道 %s 缺少url", cfg.Type)
+		}
+	case NotifierEmail:
+		if cfg.SMTPHost == "" || cfg.From == "" || len(cfg.To) == 0 {
+			return fmt.Errorf("邮件通知需要 smtp_host、from 和 to")
+		}
+	default:
+		return fmt.Errorf("不支持的通知渠道: %s", cfg.Type)
+	}
+	return nil
+}
+
+// alertText 将提醒合并为一条文本消息
+func alertText(alerts []Alert) string {
+	var b strings.Builder
+	b.WriteString("【蔬菜价格提醒】")
+	for _, a := range alerts {
+		b.WriteString("\n• ")
+		b.WriteString(a.Message)
+	}
+	return b.String()
+}
+
+// notifyClient 发送通知使用的HTTP客户端
+var notifyClient = &http.Client{Timeout: 10 * time.Second}
+
+// postJSON 发送JSON请求并返回响应内容
+func postJSON(ctx context.Context, target string, payload interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := notifyClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("发送请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("HTTP状态码异常: %d %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return respBody, nil
+}
+
+// webhookNotifier 通用webhook，POST {"text": ..., "alerts": [...]}
+type webhookNotifier struct {
+	url string
+}
+
+func (n *webhookNotifier) Name() string { return NotifierWebhook }
+
+func (n *webhookNotifier) Notify(ctx context.Context, alerts []Alert) error {
+	_, err := postJSON(ctx, n.url, map[string]interface{}{
+		"text":   alertText(alerts),
+		"alerts": alerts,
+	})
+	return err
+}
+
+// robotNotifier 企业微信、钉钉、飞书群机器人，都是POST一条文本消息
+type robotNotifier struct {
+	name   string
+	url    string
+	secret string // 钉钉、飞书的加签密钥，可选
+	now    func() time.Time
+}
+
+func (n *robotNotifier) Name() string { return n.name }
+
+func (n *robotNotifier) Notify(ctx context.Context, alerts []Alert) error {
+	now := time.Now
+	if n.now != nil {
+		now = n.now
+	}
+	text := alertText(alerts)
+	target := n.url
+
+	var payload map[string]interface{}
+	switch n.name {
+	case NotifierFeishu:
+		payload = map[string]interface{}{
+			"msg_type": "text",
+			"content":  map[string]string{"text": text},
+		}
+		if n.secret != "" {
+			timestamp := strconv.FormatInt(now().Unix(), 10)
+			payload["timestamp"] = timestamp
+			payload["sign"] = feishuSign(n.secret, timestamp)
+		}
+	default:
+		payload = map[string]interface{}{
+			"msgtype": "text",
+			"text":    map[string]string{"content": text},
+		}
+		if n.name == NotifierDingTalk && n.secret != "" {
+			timestamp := strconv.FormatInt(now().UnixMilli(), 10)
+			target = appendQuery(target, url.Values{
+				"timestamp": {timestamp},
+				"sign":      {dingTalkSign(n.secret, timestamp)},
+			})
+		}
+	}
+
+	respBody, err := postJSON(ctx, target, payload)
+	if err != nil {
+		return err
+	}
+
+	// 机器人接口失败时也返回200，需要检查响应中的错误码
+	var result struct {
+		ErrCode *int   `json:"errcode"` // 企业微信、钉钉
+		ErrMsg  string `json:"errmsg"`
+		Code    *int   `json:"code"` // 飞书
+		Msg     string `json:"msg"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil
+	}
+	if result.ErrCode != nil && *result.ErrCode != 0 {
+		return fmt.Errorf("机器人返回错误 %d: %s", *result.ErrCode, result.ErrMsg)
+	}
+	if result.Code != nil && *result.Code != 0 {
+		return fmt.Errorf("机器人返回错误 %d: %s", *result.Code, result.Msg)
+	}
+	return nil
+}
+
+// dingTalkSign 钉钉加签：HmacSHA256(secret, timestamp+"\n"+secret) 的Base64
+func dingTalkSign(secret, timestamp string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// feishuSign 飞书加签：以 timestamp+"\n"+secret 为密钥对空内容做HmacSHA256后Base64
+func feishuSign(secret, timestamp string) string {
+	mac := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// appendQuery 在URL后追加查询参数
+func appendQuery(target string, values url.Values) string {
+	if strings.Contains(target, "?") {
+		return target + "&" + values.Encode()
+	}
+	return target + "?" + values.Encode()
+}
+
+// emailNotifier SMTP邮件通知
+type emailNotifier struct {
+	cfg NotifierConfig
+}
+
+func (n *emailNotifier) Name() string { return NotifierEmail }
+
+func (n *emailNotifier) Notify(ctx context.Context, alerts []Alert) error {
+	port := n.cfg.SMTPPort
+	if port == 0 {
+		port = 25
+		if n.cfg.TLS {
+			port = 465
+		}
+	}
+	addr := net.JoinHostPort(n.cfg.SMTPHost, strconv.Itoa(port))
+
+	subject := fmt.Sprintf("蔬菜价格提醒（%d条）", len(alerts))
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: =?UTF-8?B?%s?=\r\n", base64.StdEncoding.EncodeToString([]byte(subject)))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	// Base64正文按76个字符换行
+	encoded := base64.StdEncoding.EncodeToString([]byte(alertText(alerts)))
+	for len(encoded) > 76 {
+		msg.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	msg.WriteString(encoded + "\r\n")
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	var err error
+	if n.cfg.TLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: n.cfg.SMTPHost}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("连接SMTP服务器失败: %v", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.cfg.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("连接SMTP服务器失败: %v", err)
+	}
+	defer client.Close()
+
+	// 服务器支持时升级为加密连接
+	if !n.cfg.TLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: n.cfg.SMTPHost}); err != nil {
+				return fmt.Errorf("STARTTLS失败: %v", err)
+			}
+		}
+	}
+	if n.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.SMTPHost)); err != nil {
+			return fmt.Errorf("SMTP认证失败: %v", err)
+		}
+	}
+
+	if err := client.Mail(n.cfg.From); err != nil {
+		return fmt.Errorf("设置发件人失败: %v", err)
+	}
+	for _, to := range n.cfg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("设置收件人 %s 失败: %v", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	if _, err := w.Write(msg.Bytes()); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	return client.Quit()
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRobotNotifiers 测试企业微信、钉钉、飞书机器人的请求格式、加签和错误码
+func TestRobotNotifiers(t *testing.T) {
+	var lastQuery string
+	var lastBody map[string]interface{}
+	reply := `{"errcode":0,"errmsg":"ok"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastQuery = r.URL.RawQuery
+		lastBody = nil
+		json.NewDecoder(r.Body).Decode(&lastBody)
+		fmt.Fprint(w, reply)
+	}))
+	defer server.Close()
+
+	fixed := time.Unix(1700000000, 0)
+	alerts := []Alert{{Message: "土豆 1.20元/斤 < 1.50"}}
+
+	wecom := &robotNotifier{name: NotifierWeCom, url: server.URL}
+	if err := wecom.Notify(context.Background(), alerts); err != nil {
+		t.Errorf("企业微信发送失败: %v", err)
+	}
+	if lastBody["msgtype"] != "text" {
+		t.Errorf("企业微信请求 = %v", lastBody)
+	}
+
+	dingtalk := &robotNotifier{name: NotifierDingTalk, url: server.URL + "?access_token=abc", secret: "SEC123", now: func() time.Time { return fixed }}
+	if err := dingtalk.Notify(context.Background(), alerts); err != nil {
+		t.Errorf("钉钉发送失败: %v", err)
+	}
+	query, _ := url.ParseQuery(lastQuery)
+	if query.Get("access_token") != "abc" || query.Get("timestamp") != "1700000000000" || query.Get("sign") != dingTalkSign("SEC123", "1700000000000") {
+		t.Errorf("钉钉加签参数 = %s", lastQuery)
+	}
+
+	reply = `{"code":0,"msg":"success"}`
+	feishu := &robotNotifier{name: NotifierFeishu, url: server.URL, secret: "SEC123", now: func() time.Time { return fixed }}
+	if err := feishu.Notify(context.Background(), alerts); err != nil {
+		t.Errorf("飞书发送失败: %v", err)
+	}
+	if lastBody["msg_type"] != "text" || lastBody["timestamp"] != "1700000000" || lastBody["sign"] != feishuSign("SEC123", "1700000000") {
+		t.Errorf("飞书请求 = %v", lastBody)
+	}
+
+	// 机器人返回200但错误码非0时视为失败
+	reply = `{"errcode":93000,"errmsg":"invalid webhook url"}`
+	if err := wecom.Notify(context.Background(), alerts); err == nil || !strings.Contains(err.Error(), "93000") {
+		t.Errorf("企业微信错误码未识别: %v", err)
+	}
+	reply = `{"code":19021,"msg":"sign match fail"}`
+	if err := feishu.Notify(context.Background(), alerts); err == nil {
+		t.Error("飞书错误码未识别")
+	}
+}
+
+// TestEmailNotifier 测试通过SMTP发送邮件
+func TestEmailNotifier(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// 最简单的SMTP服务器，记录收到的命令和邮件内容
+	var commands []string
+	var message strings.Builder
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		fmt.Fprint(conn, "220 localhost ESMTP\r\n")
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					fmt.Fprint(conn, "250 OK\r\n")
+				} else {
+					message.WriteString(line)
+				}
+				continue
+			}
+			command := strings.ToUpper(strings.Fields(line)[0])
+			commands = append(commands, command)
+			switch command {
+			case "EHLO", "HELO":
+				fmt.Fprint(conn, "250 localhost\r\n")
+			case "DATA":
+				inData = true
+				fmt.Fprint(conn, "354 Go ahead\r\n")
+			case "QUIT":
+				fmt.Fprint(conn, "221 Bye\r\n")
+				return
+			default:
+				fmt.Fprint(conn, "250 OK\r\n")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	notifier, err := newNotifier(NotifierConfig{
+		Type:     NotifierEmail,
+		SMTPHost: host,
+		SMTPPort: portNumber,
+		From:     "bot@example.com",
+		To:       []string{"a@example.com", "b@example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := notifier.Notify(ctx, []Alert{{Message: "土豆 1.20元/斤 < 1.50"}}); err != nil {
+		t.Fatalf("发送邮件失败: %v", err)
+	}
+	<-done
+
+	if got := strings.Join(commands, " "); got != "EHLO MAIL RCPT RCPT DATA QUIT" {
+		t.Errorf("SMTP命令 = %s", got)
+	}
+	header, body, _ := strings.Cut(message.String(), "\r\n\r\n")
+	text, _ := base64.StdEncoding.DecodeString(strings.ReplaceAll(body, "\r\n", ""))
+	if !strings.Contains(header, "To: a@example.com, b@example.com") || !strings.Contains(string(text), "土豆 1.20元/斤 < 1.50") {
+		t.Errorf("邮件内容 = %s", message.String())
+	}
+}
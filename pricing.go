@@ -0,0 +1,145 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PriceInfo 从价格文本中识别出的各种价格
+type PriceInfo struct {
+	Price         float64 // 当前售价，有促销时为促销价
+	OriginalPrice float64 // 原价（划线价）
+	MemberPrice   float64 // 会员价
+	PromoLabel    string  // 促销标签，如 特价、秒杀价、限时
+}
+
+// 价格前的标签
+var (
+	originalPriceLabels = []string{"原价", "划线价", "市场价", "门店价", "零售价", "参考价"}
+	memberPriceLabels   = []string{"会员价", "VIP价", "vip价", "会员"}
+	promoPriceLabels    = []string{"特价", "促销价", "秒杀价", "活动价", "折后价", "到手价", "限时价", "抢购价"}
+	currentPriceLabels  = []string{"现价", "售价", "价格"}
+)
+
+// promoKeywords 价格文本中没有标签价格时，用于识别促销的关键词
+var promoKeywords = []string{"限时", "特价", "秒杀", "促销", "折扣", "抢购", "直降"}
+
+// priceMentionPattern 价格文本中的一个价格：可选的标签、货币符号，数字，以及数字后的 元 或规格单位
+var priceMentionPattern = regexp.MustCompile(`([\p{Han}A-Za-z]*价格?|会员)?\s*[:：]?\s*([￥¥])?\s*(\d+(?:\.\d+)?)(\s*元|(?i:kg|g|ml|l)|千克|公斤|克|斤|两|毫升|升|个|只|袋|盒|包|份|把|根|束|箱|件|折)?`)
+
+// discountPattern 折扣，如 8.8折
+var discountPattern = regexp.MustCompile(`\d+(?:\.\d+)?\s*折`)
+
+// parsePriceText 识别价格文本中的售价、原价、会员价和促销标签，如
+// "￥3.98 原价￥5.98"、"会员价￥3.50 ￥3.98"、"特价3.98元"。
+// 没有标签的多个价格中，较低的是售价，较高的是划线价；
+// 找不到带 ￥ / 元 / 标签的价格时，按旧规则取第一个数字作为售价
+func parsePriceText(text string) PriceInfo {
+	var info PriceInfo
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return info
+	}
+
+	var unlabeled []float64
+	for _, m := range priceMentionPattern.FindAllStringSubmatch(text, -1) {
+		label, currency, number, suffix := m[1], m[2], m[3], strings.TrimSpace(m[4])
+		// 数字后是重量、数量单位或折扣时不是价格
+		if suffix != "" && suffix != "元" {
+			continue
+		}
+		if label == "" && currency == "" && suffix == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(number, 64)
+		if err != nil || value <= 0 {
+			continue
+		}
+
+		switch {
+		case label == "":
+			unlabeled = append(unlabeled, value)
+		case hasLabel(label, originalPriceLabels):
+			if info.OriginalPrice == 0 {
+				info.OriginalPrice = value
+			}
+		case hasLabel(label, memberPriceLabels):
+			if info.MemberPrice == 0 {
+				info.MemberPrice = value
+			}
+		case hasLabel(label, promoPriceLabels):
+			if info.Price == 0 {
+				info.Price = value
+				info.PromoLabel = promoLabelFor(label)
+			}
+		case hasLabel(label, currentPriceLabels):
+			if info.Price == 0 {
+				info.Price = value
+			}
+		default:
+			unlabeled = append(unlabeled, value)
+		}
+	}
+
+	for _, value := range unlabeled {
+		switch {
+		case info.Price == 0:
+			info.Price = value
+		case info.OriginalPrice == 0 && value > info.Price:
+			info.OriginalPrice = value
+		case info.OriginalPrice == 0 && value < info.Price && info.PromoLabel == "":
+			info.Price, info.OriginalPrice = value, info.Price
+		}
+	}
+
+	switch {
+	case info.Price > 0:
+	case info.OriginalPrice > 0:
+		// 只标了原价时就是售价
+		info.Price, info.OriginalPrice = info.OriginalPrice, 0
+	case info.MemberPrice > 0:
+		// 只有会员价时按会员价计算
+		info.Price = info.MemberPrice
+	default:
+		info.Price, _ = strconv.ParseFloat(cleanPriceText(text), 64)
+	}
+
+	if info.PromoLabel == "" {
+		info.PromoLabel = findPromoKeyword(text)
+	}
+	return info
+}
+
+// hasLabel 标签是否以列表中的某一项结尾，如 “今日特价” 属于 特价
+func hasLabel(label string, labels []string) bool {
+	for _, l := range labels {
+		if strings.HasSuffix(label, l) {
+			return true
+		}
+	}
+	return false
+}
+
+// promoLabelFor 促销价标签对应的显示文本，如 今日秒杀价 -> 秒杀价
+func promoLabelFor(label string) string {
+	for _, l := range promoPriceLabels {
+		if strings.HasSuffix(label, l) {
+			return l
+		}
+	}
+	return label
+}
+
+// findPromoKeyword 查找折扣或促销关键词
+func findPromoKeyword(text string) string {
+	if m := discountPattern.FindString(text); m != "" {
+		return strings.ReplaceAll(m, " ", "")
+	}
+	for _, keyword := range promoKeywords {
+		if strings.Contains(text, keyword) {
+			return keyword
+		}
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// TestCleanPriceText 测试价格文本清理功能
+func TestCleanPriceText(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"¥12.50", "12.50"},
+		{"$15.99", "15.99"},
+		{"价格：8.80元", "8.80"},
+		{"特价 5.5", "5.5"},
+		{"免费", "0"},
+		{"", "0"},
+	}
+
+	for _, tc := range testCases {
+		result := cleanPriceText(tc.input)
+		if result != tc.expected {
+			t.Errorf("cleanPriceText(%q) = %s, 期望 %s", tc.input, result, tc.expected)
+		}
+	}
+}
+
+// TestParsePriceText 测试价格文本中售价、原价、会员价和促销标签的识别
+func TestParsePriceText(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  PriceInfo
+	}{
+		{"￥3.98", PriceInfo{Price: 3.98}},
+		{"￥3.98 原价￥5.98", PriceInfo{Price: 3.98, OriginalPrice: 5.98}},
+		{"原价：5.98元 现价：3.98元", PriceInfo{Price: 3.98, OriginalPrice: 5.98}},
+		// 没有标签时较低的是售价
+		{"￥5.98 ￥3.98", PriceInfo{Price: 3.98, OriginalPrice: 5.98}},
+		{"会员价￥3.50 ￥3.98", PriceInfo{Price: 3.98, MemberPrice: 3.50}},
+		{"￥3.98 VIP价：3.5", PriceInfo{Price: 3.98, MemberPrice: 3.5}},
+		{"今日特价3.98元 ￥5.98", PriceInfo{Price: 3.98, OriginalPrice: 5.98, PromoLabel: "特价"}},
+		{"秒杀价 ￥1.99 原价 ￥2.99 会员价 ￥1.89", PriceInfo{Price: 1.99, OriginalPrice: 2.99, MemberPrice: 1.89, PromoLabel: "秒杀价"}},
+		{"限时 ￥2.50", PriceInfo{Price: 2.50, PromoLabel: "限时"}},
+		{"￥9.90 8.8折", PriceInfo{Price: 9.90, PromoLabel: "8.8折"}},
+		// 规格中的数字不是价格
+		{"￥3.98/500g", PriceInfo{Price: 3.98}},
+		{"2斤装 ￥6.00", PriceInfo{Price: 6.00}},
+		{"会员价￥3.50", PriceInfo{Price: 3.50, MemberPrice: 3.50}},
+		{"原价￥5.98", PriceInfo{Price: 5.98}},
+		// 没有货币符号时按旧规则取第一个数字
+		{"3.98", PriceInfo{Price: 3.98}},
+		{"", PriceInfo{}},
+	}
+
+	for _, tc := range testCases {
+		if got := parsePriceText(tc.input); got != tc.want {
+			t.Errorf("parsePriceText(%q) = %+v, 期望 %+v", tc.input, got, tc.want)
+		}
+	}
+}
+
+// TestPromoPricePerJin 测试原价、会员价按规格分别计算每斤价格
+func TestPromoPricePerJin(t *testing.T) {
+	page := `<div class="index_picAD">
+<div><a href="/p/1"></a><h3>蒜苔</h3><span class="price">￥3.98 <del>￥5.98</del></span><span class="vip_price">会员价￥3.58</span><span class="spec">250g</span></div>
+<div><a href="/p/2"></a><h3>鸡蛋 盒装</h3><span class="price">特价￥9.90 原价￥12.90</span><span class="spec">1盒</span></div>
+</div>`
+	products, err := parseHTML(context.Background(), page)
+	if err != nil || len(products) != 2 {
+		t.Fatalf("parseHTML = %+v, err=%v", products, err)
+	}
+
+	garlic := products[0]
+	if garlic.Price != 3.98 || garlic.OriginalPrice != 5.98 || garlic.MemberPrice != 3.58 {
+		t.Errorf("蒜苔价格 = %+v", garlic)
+	}
+	if garlic.PricePerJin != 7.96 || garlic.OriginalPricePerJin != 11.96 || garlic.MemberPricePerJin != 7.16 {
+		t.Errorf("蒜苔每斤价格 = %v / %v / %v", garlic.PricePerJin, garlic.OriginalPricePerJin, garlic.MemberPricePerJin)
+	}
+	if d := garlic.DiscountPerJin(); d < 3.999 || d > 4.001 {
+		t.Errorf("蒜苔每斤便宜 %v, 期望 4", d)
+	}
+
+	eggs := products[1]
+	if !eggs.IsPackaged || eggs.PricePerJin != 9.90 || eggs.OriginalPricePerJin != 12.90 || eggs.PromoLabel != "特价" {
+		t.Errorf("鸡蛋 = %+v", eggs)
+	}
+}
+
+// TestCalculatePricePerJin 测试每斤价格计算功能
+func TestCalculatePricePerJin(t *testing.T) {
+	testCases := []struct {
+		price    float64
+		spec     string
+		expected float64
+	}{
+		{10.0, "1斤", 10.0},   // 10元/斤
+		{20.0, "2斤", 10.0},   // 20元/2斤 = 10元/斤
+		{15.0, "500g", 15.0}, // 15元/500g = 15元/斤
+		{0, "1斤", 0},         // 价格为0
+		{10.0, "", 0},        // 无规格
+	}
+
+	for _, tc := range testCases {
+		result := calculatePricePerJin(tc.price, tc.spec)
+		if result != tc.expected {
+			t.Errorf("calculatePricePerJin(%.2f, %q) = %.2f, 期望 %.2f", tc.price, tc.spec, result, tc.expected)
+		}
+	}
+}
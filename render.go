@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// templateFuncs 页面模板可用的函数，输出都会经过 html/template 的上下文转义
+var templateFuncs = template.FuncMap{
+	"price":   formatPrice,
+	"percent": formatPercent,
+	"unit":    displayUnit,
+	"asset":   assetPath,
+	"inline":  inlineCSS,
+}
+
+// formatPrice 价格保留两位小数
+func formatPrice(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
+
+// formatPercent 带符号的百分比，如 +12.5%
+func formatPercent(v float64) string {
+	return fmt.Sprintf("%+.1f%%", v)
+}
+
+// displayUnit 价格单位的显示文本，没有单位时按元显示
+func displayUnit(unit string) string {
+	unit = strings.TrimSpace(unit)
+	if unit == "" {
+		return "元"
+	}
+	return unit
+}
+
+// renderProductList 渲染商品列表页面，templateDir 为空时使用内置模板
+func renderProductList(templateDir string, data PageData) (string, error) {
+	tmpl, err := productListTemplateFor(templateDir)
+	if err != nil {
+		return "", fmt.Errorf("解析模板失败: %v", err)
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("执行模板失败: %v", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestProductListShowsFetchErrors 测试抓取失败的分类在页面上显示提示
+func TestProductListShowsFetchErrors(t *testing.T) {
+	now := time.Now()
+	categories := []Category{
+		{ID: "fruit-vegetable", Name: "瓜果花菜类", Status: CategoryStatusOK, FetchedAt: now,
+			Products: []Product{{ID: "1", Name: "西红柿", Price: 3.98, Spec: "500g", PricePerJin: 3.98, Unit: "元/斤"}}},
+		{ID: "leaf-vegetable", Name: "叶菜类", Status: CategoryStatusFailed, Error: "第4次请求失败: HTTP状态码异常: 502", FetchedAt: now},
+	}
+	data := PageData{Categories: categories, Errors: collectCategoryErrors(categories)}
+
+	out, err := renderProductList("", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`id="fetchWarning"`, "以下分类暂时无法获取最新价格：叶菜类", "该分类获取失败：第4次请求失败"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("页面中缺少 %q", want)
+		}
+	}
+	if len(data.Errors) != 1 || data.Errors[0].CategoryID != "leaf-vegetable" {
+		t.Errorf("collectCategoryErrors = %+v, 期望只包含叶菜类", data.Errors)
+	}
+}
+
+// TestProductListEscapesProductNames 测试第三方页面中的恶意商品信息在输出页面中被转义
+func TestProductListEscapesProductNames(t *testing.T) {
+	page := `<html><body><div class="index_picAD">
+<div><a href="x&quot; onmouseover=&quot;alert(1)"></a><h3>&lt;script&gt;alert(&#39;name&#39;)&lt;/script&gt;西红柿</h3>
+<span class="price">￥3.98</span><span class="spec">&lt;img src=x onerror=alert(2)&gt;500g</span></div>
+<div><a href="javascript:alert(3)"></a><h3>&lt;/span&gt;&lt;iframe src=//evil&gt;土豆</h3><span class="price">￥1.50</span><span class="spec">1斤</span></div>
+</div></body></html>`
+
+	products, err := parseHTML(context.Background(), page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 2 || !strings.Contains(products[0].Name, "<script>") {
+		t.Fatalf("parseHTML 结果 = %+v", products)
+	}
+
+	category := Category{ID: "fruit-vegetable", Name: "<b>瓜果</b>", Status: CategoryStatusOK, FetchedAt: time.Now()}
+	for _, p := range products {
+		category.Products = append(category.Products, *p)
+	}
+	out, err := renderProductList("", PageData{Categories: []Category{category}, Errors: []CategoryError{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, bad := range []string{"<script>alert", "<img src=x", "<iframe", `" onmouseover="`, "<b>瓜果"} {
+		if strings.Contains(out, bad) {
+			t.Errorf("页面中包含未转义的 %q", bad)
+		}
+	}
+	for _, want := range []string{"&lt;script&gt;alert(&#39;name&#39;)&lt;/script&gt;西红柿", "&lt;img src=x onerror=alert(2)&gt;500g", "&lt;b&gt;瓜果&lt;/b&gt;", "3.98元/斤"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("页面中缺少转义后的 %q", want)
+		}
+	}
+}
+
+// TestTemplateFuncs 测试模板中的格式化函数
+func TestTemplateFuncs(t *testing.T) {
+	cases := []struct{ got, want string }{
+		{formatPrice(3.985), "3.98"},
+		{formatPrice(2), "2.00"},
+		{formatPercent(12.345), "+12.3%"},
+		{formatPercent(-20), "-20.0%"},
+		{displayUnit("元/斤"), "元/斤"},
+		{displayUnit(" "), "元"},
+	}
+	for _, c := range cases {
+		if c.got != c.want {
+			t.Errorf("得到 %q, 期望 %q", c.got, c.want)
+		}
+	}
+}
+
+// TestTemplateClassesDefined 测试页面中用到的类名都在 tailwind.css、font-awesome.min.css 或页面内的 <style> 中定义，
+// tailwind.css 是手工精简的，页面新增 Tailwind 类名时容易漏加
+func TestTemplateClassesDefined(t *testing.T) {
+	page, err := embeddedAssets.ReadFile("product_list.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var css strings.Builder
+	for _, name := range []string{"static/css/tailwind.css", "static/css/font-awesome.min.css"} {
+		data, err := embeddedAssets.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		css.Write(data)
+	}
+	for _, m := range regexp.MustCompile(`(?s)<style>(.*?)</style>`).FindAllStringSubmatch(string(page), -1) {
+		css.WriteString(m[1])
+	}
+	defined := make(map[string]bool)
+	for _, m := range regexp.MustCompile(`\.((?:[\w-]|\\.)+)`).FindAllStringSubmatch(css.String(), -1) {
+		defined[strings.ReplaceAll(m[1], `\`, "")] = true
+	}
+
+	// 去掉模板动作和JS模板字符串中的表达式，再收集 class 属性、className 和 classList 中的类名
+	text := regexp.MustCompile(`(?s)\{\{.*?\}\}|\$\{.*?\}`).ReplaceAllString(string(page), " ")
+	var used []string
+	for _, m := range regexp.MustCompile("class(?:Name)?\\s*=\\s*[\"'`]([^\"'`]*)").FindAllStringSubmatch(text, -1) {
+		used = append(used, strings.Fields(m[1])...)
+	}
+	for _, m := range regexp.MustCompile(`classList\.\w+\(([^)]*)\)`).FindAllStringSubmatch(text, -1) {
+		for _, q := range regexp.MustCompile(`'([^']*)'`).FindAllStringSubmatch(m[1], -1) {
+			used = append(used, q[1])
+		}
+	}
+	if len(used) < 50 {
+		t.Fatalf("只找到 %d 个类名，解析规则可能有误", len(used))
+	}
+
+	// 只用于脚本查找元素或划分结构、不需要样式的类名
+	hooks := map[string]bool{"tab-btn": true, "category-content": true, "product-list": true, "store-switch": true, "unit-switch": true}
+	reported := make(map[string]bool)
+	for _, class := range used {
+		if !defined[class] && !hooks[class] && !reported[class] {
+			reported[class] = true
+			t.Errorf("类名 %s 没有定义，请添加到 static/css/tailwind.css 或页面的 <style> 中", class)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResponseSchemaVersion JSON响应的结构版本，字段含义变化或删除字段时递增，新增字段不递增
+const ResponseSchemaVersion = 1
+
+// 页面入口支持的输出格式
+const (
+	FormatHTML = "html" // 商品列表页面
+	FormatJSON = "json" // ProductListResponse
+	FormatCSV  = "csv"  // 每行一个商品
+)
+
+// formatMediaTypes 输出格式对应的媒体类型，按 Accept 协商时同等优先级的靠前
+var formatMediaTypes = []struct {
+	format    string
+	mediaType string
+}{
+	{FormatHTML, "text/html"},
+	{FormatJSON, "application/json"},
+	{FormatCSV, "text/csv"},
+}
+
+// negotiateFormat 确定输出格式：format 参数优先，否则按 Accept 请求头协商，都没有时为页面。
+// format 参数无效或 Accept 中没有支持的类型时返回错误
+func negotiateFormat(format, accept string) (string, error) {
+	if format != "" {
+		format = strings.ToLower(strings.TrimSpace(format))
+		for _, f := range formatMediaTypes {
+			if f.format == format {
+				return format, nil
+			}
+		}
+		return "", fmt.Errorf("不支持的格式: %s，支持 html / json / csv", format)
+	}
+	if strings.TrimSpace(accept) == "" {
+		return FormatHTML, nil
+	}
+
+	best, bestQ := "", 0.0
+	for _, f := range formatMediaTypes {
+		if q := acceptQuality(accept, f.mediaType); q > bestQ {
+			best, bestQ = f.format, q
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("不支持 Accept 中的类型: %s，支持 text/html / application/json / text/csv", accept)
+	}
+	return best, nil
+}
+
+// acceptQuality 媒体类型在 Accept 请求头中的权重，完全匹配优先于 text/* 和 */*，没有匹配时为0
+func acceptQuality(accept, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	q, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		rangeType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		s := -1
+		switch rangeType {
+		case mediaType:
+			s = 2
+		case mainType + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		}
+		if s <= specificity {
+			continue
+		}
+		specificity, q = s, 1
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+	}
+	return q
+}
+
+// ResponseMeta 本次抓取的汇总信息
+type ResponseMeta struct {
+	GeneratedAt        time.Time     `json:"generated_at"`          // 生成响应的时间
+	PriceUnit          PriceUnit     `json:"price_unit"`            // 重量商品价格的显示单位
+	Store              string        `json:"store,omitempty"`       // 选择的门店参数
+	Stores             []StoreConfig `json:"stores,omitempty"`      // 配置的所有门店
+	CategoryCount      int           `json:"category_count"`        // 分类数量，多门店时按门店分别计算
+	ProductCount       int           `json:"product_count"`         // 所有分类的商品总数
+	FailedCount        int           `json:"failed_count"`          // 抓取失败的分类数量
+	StaleCount         int           `json:"stale_count"`           // 使用旧数据的分类数量
+	Partial            bool          `json:"partial"`               // 是否有分类抓取失败或使用旧数据
+	OldestFetchedAt    *time.Time    `json:"oldest_fetched_at"`     // 最早的抓取时间，没有成功的分类时为null
+	MaxCacheAgeSeconds int64         `json:"max_cache_age_seconds"` // 最旧数据的缓存时长，同 Age 响应头
+}
+
+// ProductListResponse 页面入口的JSON响应，与页面展示相同的数据
+type ProductListResponse struct {
+	SchemaVersion int               `json:"schema_version"` // 结构版本，见 ResponseSchemaVersion
+	Meta          ResponseMeta      `json:"meta"`
+	Categories    []Category        `json:"categories"`           // 所有分类，抓取失败的分类 products 为空
+	Errors        []CategoryError   `json:"errors"`               // 抓取失败或使用旧数据的分类
+	Diff          *PriceDiff        `json:"diff,omitempty"`       // 与今日零点前相比的价格变化，未启用历史存储时省略
+	Comparison    *ComparisonReport `json:"comparison,omitempty"` // 跨门店比价，有多个门店或网站的数据时才有
+	Shopping      *ShoppingResult   `json:"shopping,omitempty"`   // 购物清单的花费，请求中有清单时才有
+	Watchlist     *Watchlist        `json:"watchlist,omitempty"`  // 服务端收藏夹，请求中有收藏夹ID时才有
+}
+
+// newProductListResponse 由页面数据生成JSON响应
+func newProductListResponse(data PageData, at time.Time) ProductListResponse {
+	meta := ResponseMeta{
+		GeneratedAt:        at,
+		PriceUnit:          data.PriceUnit,
+		Store:              data.Store,
+		Stores:             data.Stores,
+		CategoryCount:      len(data.Categories),
+		MaxCacheAgeSeconds: data.maxCacheAge(),
+	}
+	for _, c := range data.Categories {
+		meta.ProductCount += len(c.Products)
+		switch c.Status {
+		case CategoryStatusFailed:
+			meta.FailedCount++
+			continue
+		case CategoryStatusStale:
+			meta.StaleCount++
+		}
+		if meta.OldestFetchedAt == nil || c.FetchedAt.Before(*meta.OldestFetchedAt) {
+			fetchedAt := c.FetchedAt
+			meta.OldestFetchedAt = &fetchedAt
+		}
+	}
+	meta.Partial = meta.FailedCount+meta.StaleCount > 0
+
+	errs := data.Errors
+	if errs == nil {
+		errs = make([]CategoryError, 0)
+	}
+	categories := data.Categories
+	if categories == nil {
+		categories = make([]Category, 0)
+	}
+	return ProductListResponse{
+		SchemaVersion: ResponseSchemaVersion,
+		Meta:          meta,
+		Categories:    categories,
+		Errors:        errs,
+		Diff:          data.Diff,
+		Comparison:    data.Comparison,
+		Shopping:      data.Shopping,
+		Watchlist:     data.Watchlist,
+	}
+}
+
+// productCSVHeader CSV的表头，与JSON中的字段名相同；price_per_unit 为按 price_unit 换算后的价格
+var productCSVHeader = []string{
+	"store", "store_name", "category_id", "category_name", "category_status",
+	"product_id", "name", "canonical_key", "spec", "is_packaged",
+	"price", "unit", "price_per_jin", "price_per_unit", "price_unit", "weight_estimated",
+	"original_price", "member_price", "promo_label", "stock", "fetched_at",
+}
+
+// writeProductsCSV 以CSV输出所有商品，每行一个商品，抓取失败的分类没有商品行。
+// 开头写入UTF-8 BOM，Excel打开时中文不会乱码；文本列见 csvText
+func writeProductsCSV(w io.Writer, data PageData) error {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(productCSVHeader); err != nil {
+		return err
+	}
+	unit := data.PriceUnit.orDefault()
+	for _, c := range data.Categories {
+		for _, p := range c.Products {
+			record := []string{
+				csvText(c.Store), csvText(c.StoreName), csvText(c.ID), csvText(c.Name), c.Status,
+				csvText(p.ID), csvText(p.Name), csvText(p.CanonicalKey), csvText(p.Spec), strconv.FormatBool(p.IsPackaged),
+				csvFloat(p.Price), csvText(p.Unit), csvFloat(p.PricePerJin), csvFloat(unit.Convert(p.PricePerJin, p.Unit)), csvText(unit.Display(p.Unit)), strconv.FormatBool(p.WeightEstimated),
+				csvFloat(p.OriginalPrice), csvFloat(p.MemberPrice), csvText(p.PromoLabel), csvText(p.Stock), c.FetchedAt.Format(time.RFC3339),
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvText 文本列的内容，以 = + - @ 制表符或回车开头时前面加 '，
+// 商品名称等来自第三方网站，避免在Excel等表格软件中被当作公式执行
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// csvFloat 保留两位小数并去掉末尾的0，值为0（页面中没有或无法计算）时为空
+func csvFloat(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
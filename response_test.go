@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestResponseFormats 测试输出格式协商、JSON响应的汇总信息和CSV输出
+func TestResponseFormats(t *testing.T) {
+	testCases := []struct {
+		format, accept string
+		want           string // 为空表示应返回错误
+	}{
+		{"", "", FormatHTML},
+		{"", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", FormatHTML},
+		{"", "*/*", FormatHTML},
+		{"", "application/json", FormatJSON},
+		{"", "text/csv, application/json;q=0.5", FormatCSV},
+		{"", "text/*;q=0.3, application/json;q=0.6", FormatJSON},
+		{"", "text/html;q=0, */*", FormatJSON},
+		{"", "image/png", ""},
+		{"CSV", "application/json", FormatCSV},
+		{"xml", "", ""},
+	}
+	for _, tc := range testCases {
+		got, err := negotiateFormat(tc.format, tc.accept)
+		if tc.want == "" {
+			if err == nil {
+				t.Errorf("negotiateFormat(%q, %q) = %s, 期望返回错误", tc.format, tc.accept, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("negotiateFormat(%q, %q) = %s, %v, 期望 %s", tc.format, tc.accept, got, err, tc.want)
+		}
+	}
+
+	now := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	unit, _ := parsePriceUnit("kg")
+	data := PageData{
+		Categories: []Category{
+			{ID: "fruit-vegetable", Name: "瓜果花菜类", Store: "012", StoreName: "文华路店", Status: CategoryStatusOK, FetchedAt: now, Products: []Product{
+				{ID: "p1", Name: "西红柿, 精品", CanonicalKey: "西红柿", Spec: "500g", Price: 3.98, PricePerJin: 3.98, Unit: "元/斤"},
+				{ID: "p2", Name: "线椒（盒）", Spec: "1盒", Price: 5.8, PricePerJin: 5.8, IsPackaged: true, Unit: "元/盒", PromoLabel: "特价"},
+			}},
+			{ID: "leaf-vegetable", Name: "叶菜类", Status: CategoryStatusStale, FetchedAt: now.Add(-time.Hour), CacheAgeSeconds: 3600, Products: []Product{
+				{ID: "l1", Name: "菠菜", Price: 2.5, Unit: "元/把"},
+			}},
+			{ID: "mushroom", Name: "菌菇类", Status: CategoryStatusFailed, FetchedAt: now.Add(-2 * time.Hour), Products: []Product{}},
+		},
+		PriceUnit: unit,
+	}
+	data.Errors = collectCategoryErrors(data.Categories)
+
+	resp := newProductListResponse(data, now)
+	meta := resp.Meta
+	if resp.SchemaVersion != ResponseSchemaVersion || meta.CategoryCount != 3 || meta.ProductCount != 3 || meta.FailedCount != 1 || meta.StaleCount != 1 || !meta.Partial {
+		t.Errorf("响应汇总信息 = %+v", meta)
+	}
+	if meta.OldestFetchedAt == nil || !meta.OldestFetchedAt.Equal(now.Add(-time.Hour)) || meta.MaxCacheAgeSeconds != 3600 {
+		t.Errorf("最早抓取时间 = %v, 缓存时长 = %d, 期望不计抓取失败的分类", meta.OldestFetchedAt, meta.MaxCacheAgeSeconds)
+	}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"schema_version":1`, `"errors":[{"category_id":"leaf-vegetable"`, `"partial":true`} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("JSON中缺少 %s", want)
+		}
+	}
+
+	var buf strings.Builder
+	if err := writeProductsCSV(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "\ufeffstore,store_name,category_id,") {
+		t.Errorf("CSV应以BOM和表头开头: %q", out[:40])
+	}
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(out, "\ufeff"))).ReadAll()
+	if err != nil {
+		t.Fatalf("解析CSV失败: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("CSV共 %d 行, 期望表头和3个商品", len(records))
+	}
+	row := make(map[string]string)
+	for i, name := range records[0] {
+		row[name] = records[1][i]
+	}
+	if row["name"] != "西红柿, 精品" || row["price_per_jin"] != "3.98" || row["price_per_unit"] != "7.96" || row["price_unit"] != "元/公斤" || row["store_name"] != "文华路店" || row["fetched_at"] != "2025-03-01T08:00:00Z" {
+		t.Errorf("CSV第一个商品 = %v", row)
+	}
+	for i, name := range records[0] {
+		row[name] = records[3][i]
+	}
+	if row["category_status"] != CategoryStatusStale || row["price_per_jin"] != "" || row["price_per_unit"] != "" {
+		t.Errorf("无法计算每斤价格的商品 = %v", row)
+	}
+
+	// 第三方网站的文本以公式字符开头时加 ' 前缀，数字列不变
+	data.Categories = []Category{{ID: "@cat", Name: "+分类", Status: CategoryStatusOK, FetchedAt: now, Products: []Product{
+		{ID: "-1", Name: `=HYPERLINK("http://evil","点击")`, CanonicalKey: "\t西红柿", Spec: "\r500g", Price: 3.98, PricePerJin: 3.98, Unit: "元/斤", PromoLabel: "+1", Stock: "@SUM(A1)"},
+	}}}
+	buf.Reset()
+	if err := writeProductsCSV(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+	if records, err = csv.NewReader(strings.NewReader(strings.TrimPrefix(buf.String(), "\ufeff"))).ReadAll(); err != nil || len(records) != 2 {
+		t.Fatalf("解析CSV失败: %v, %d 行", err, len(records))
+	}
+	for i, name := range records[0] {
+		row[name] = records[1][i]
+	}
+	for name, want := range map[string]string{
+		"category_id": "'@cat", "category_name": "'+分类", "product_id": "'-1", "name": `'=HYPERLINK("http://evil","点击")`,
+		"canonical_key": "'\t西红柿", "spec": "'\r500g", "promo_label": "'+1", "stock": "'@SUM(A1)", "price": "3.98",
+	} {
+		if row[name] != want {
+			t.Errorf("CSV列 %s = %q, 期望 %q", name, row[name], want)
+		}
+	}
+}
+
+// TestErrorBody 测试阿里云函数的错误响应为合法JSON，错误信息中的引号、反斜杠和中文原样返回
+func TestErrorBody(t *testing.T) {
+	config := &Config{Stores: []StoreConfig{{ID: "012", Name: "文华路店"}}}
+	store := `a"b\c` + "\n店"
+	resp, err := HandleHttpRequestWithHtml(t.Context(), AliyunFunctionRequest{Format: FormatJSON, Store: store, config: config})
+	if err != nil || resp.StatusCode != 400 {
+		t.Fatalf("不存在的门店 = %d, %v, 期望 400", resp.StatusCode, err)
+	}
+	var body map[string]string
+	if err := json.Unmarshal([]byte(resp.Body), &body); err != nil {
+		t.Fatalf("错误响应不是合法JSON: %v, %s", err, resp.Body)
+	}
+	if !strings.Contains(body["error"], store) {
+		t.Errorf("错误信息 = %q, 期望包含 %q", body["error"], store)
+	}
+}
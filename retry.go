@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy 重试策略
+type RetryPolicy struct {
+	MaxRetries int           // 最大重试次数（不含首次请求）
+	BaseDelay  time.Duration // 首次重试的基础等待时间
+	MaxDelay   time.Duration // 单次等待时间上限
+}
+
+// FetchAttempt 单次请求尝试的记录，用于调试输出
+type FetchAttempt struct {
+	Attempt    int    `json:"attempt"`               // 第几次尝试（从1开始）
+	StatusCode int    `json:"status_code,omitempty"` // HTTP状态码
+	Error      string `json:"error,omitempty"`       // 错误信息
+	Retryable  bool   `json:"retryable"`             // 是否可重试
+	DurationMs int64  `json:"duration_ms"`           // 本次请求耗时（毫秒）
+	WaitMs     int64  `json:"wait_ms,omitempty"`     // 下次重试前的等待时间（毫秒）
+}
+
+// FetchResult 页面获取结果
+type FetchResult struct {
+	Body     string         // 响应内容
+	Attempts []FetchAttempt // 每次尝试的详情
+}
+
+// statusError 非2xx响应
+type statusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("HTTP状态码异常: %d", e.StatusCode)
+}
+
+// newRetryPolicy 根据配置生成重试策略
+func newRetryPolicy(config *Config) RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: config.RetryCount,
+		BaseDelay:  time.Duration(config.RetryBaseDelayMs) * time.Millisecond,
+		MaxDelay:   time.Duration(config.RetryMaxDelayMs) * time.Millisecond,
+	}
+}
+
+// backoff 计算第n次重试（从1开始）前的等待时间：指数退避 + 抖动
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay << (retry - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	// 保留一半固定等待，另一半随机，避免多个分类同时重试
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// isRetryable 判断错误是否可以重试
+func isRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	// 调用方取消或超时，不再重试
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var se *statusError
+	if errors.As(err, &se) {
+		switch {
+		case se.StatusCode == http.StatusTooManyRequests,
+			se.StatusCode == http.StatusRequestTimeout,
+			se.StatusCode >= 500:
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && (dnsErr.IsTemporary || dnsErr.IsTimeout) {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// parseRetryAfter 解析Retry-After响应头（秒数或HTTP日期）
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// fetchPage 获取页面内容，按重试策略对临时性错误进行重试
+func fetchPage(ctx context.Context, client *http.Client, url, cookie, userAgent string, policy RetryPolicy) (*FetchResult, error) {
+	result := &FetchResult{}
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		body, err := fetchOnce(ctx, client, url, cookie, userAgent)
+		record := FetchAttempt{
+			Attempt:    attempt,
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		if err == nil {
+			record.StatusCode = http.StatusOK
+			result.Attempts = append(result.Attempts, record)
+			result.Body = body
+			return result, nil
+		}
+
+		record.Error = err.Error()
+		record.Retryable = isRetryable(err)
+		var se *statusError
+		if errors.As(err, &se) {
+			record.StatusCode = se.StatusCode
+		}
+
+		if !record.Retryable || attempt > policy.MaxRetries {
+			result.Attempts = append(result.Attempts, record)
+			return result, fmt.Errorf("第%d次请求失败: %w", attempt, err)
+		}
+
+		// 计算等待时间，429/503优先使用Retry-After；要求等待的时间超过单次等待上限时直接放弃，
+		// 避免服务端返回很长的 Retry-After 时命令行一直等待
+		if se != nil && se.RetryAfter > policy.MaxDelay {
+			result.Attempts = append(result.Attempts, record)
+			return result, fmt.Errorf("第%d次请求失败，Retry-After %v 超过重试等待上限 %v: %w", attempt, se.RetryAfter, policy.MaxDelay, err)
+		}
+		wait := policy.backoff(attempt)
+		if se != nil && se.RetryAfter > wait {
+			wait = se.RetryAfter
+		}
+		record.WaitMs = wait.Milliseconds()
+		result.Attempts = append(result.Attempts, record)
+
+		// 等待时间超出调用方的截止时间，直接放弃
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return result, fmt.Errorf("第%d次请求失败，剩余时间不足以重试: %w", attempt, err)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, fmt.Errorf("等待重试时被取消: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// fetchOnce 发送一次GET请求并读取响应内容
+func fetchOnce(ctx context.Context, client *http.Client, url, cookie, userAgent string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	req.Header.Set("Cookie", cookie)
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("发送请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// 丢弃响应体以便复用连接
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		return "", &statusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	return string(body), nil
+}
+
+// printFetchAttempts 打印每次尝试的详情
+func printFetchAttempts(attempts []FetchAttempt) {
+	for _, a := range attempts {
+		line := fmt.Sprintf("  第%d次请求: 耗时%dms", a.Attempt, a.DurationMs)
+		if a.StatusCode != 0 {
+			line += fmt.Sprintf(", 状态码%d", a.StatusCode)
+		}
+		if a.Error != "" {
+			line += fmt.Sprintf(", 错误: %s", a.Error)
+			if a.Retryable && a.WaitMs > 0 {
+				line += fmt.Sprintf(" (可重试, %dms后重试)", a.WaitMs)
+			} else if a.Retryable {
+				line += " (可重试, 已达重试上限)"
+			} else {
+				line += " (不可重试)"
+			}
+		}
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestParseRetryAfter 测试Retry-After解析
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"-1", 0},
+		{"Wed, 01 Jan 2025 08:00:10 GMT", 10 * time.Second},
+		{"Wed, 01 Jan 2025 07:59:00 GMT", 0}, // 已过期
+		{"abc", 0},
+	}
+
+	for _, tc := range testCases {
+		result := parseRetryAfter(tc.value, now)
+		if result != tc.expected {
+			t.Errorf("parseRetryAfter(%q) = %v, 期望 %v", tc.value, result, tc.expected)
+		}
+	}
+}
+
+// TestFetchPageRetry 测试临时性错误重试与致命错误直接失败
+func TestFetchPageRetry(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+	// 前两次返回503，第三次成功
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	page, err := fetchPage(context.Background(), server.Client(), server.URL, "", "", policy)
+	if err != nil {
+		t.Fatalf("fetchPage 返回错误: %v", err)
+	}
+	if page.Body != "ok" || len(page.Attempts) != 3 {
+		t.Errorf("fetchPage 结果 body=%q attempts=%d, 期望 body=ok attempts=3", page.Body, len(page.Attempts))
+	}
+
+	// 404不可重试
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+
+	page, err = fetchPage(context.Background(), notFound.Client(), notFound.URL, "", "", policy)
+	if err == nil {
+		t.Fatal("fetchPage 对404应返回错误")
+	}
+	if len(page.Attempts) != 1 || page.Attempts[0].Retryable {
+		t.Errorf("fetchPage 对404应只请求一次且不可重试, 实际 %+v", page.Attempts)
+	}
+
+	// 重试次数用尽
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	page, err = fetchPage(context.Background(), failing.Client(), failing.URL, "", "", policy)
+	if err == nil || len(page.Attempts) != policy.MaxRetries+1 {
+		t.Errorf("fetchPage 重试用尽后应失败并尝试%d次, 实际 err=%v attempts=%d", policy.MaxRetries+1, err, len(page.Attempts))
+	}
+
+	// Retry-After 超过单次等待上限时不等待，直接失败
+	for _, retryAfter := range []string{"86400", time.Now().Add(24 * time.Hour).UTC().Format(http.TimeFormat)} {
+		limited := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		start := time.Now()
+		page, err = fetchPage(context.Background(), limited.Client(), limited.URL, "", "", policy)
+		limited.Close()
+		if err == nil || len(page.Attempts) != 1 || time.Since(start) > time.Second {
+			t.Errorf("Retry-After: %s 应直接失败, err=%v attempts=%d", retryAfter, err, len(page.Attempts))
+		}
+	}
+}
@@ -0,0 +1,553 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Selector 编译后的CSS选择器，支持：
+//   - 类型、通配符、#id、.class（完整类名）
+//   - 属性：[attr]、[attr=v]、[attr~=v]、[attr|=v]、[attr^=v]、[attr$=v]、[attr*=v]
+//   - 伪类：:nth-child(an+b / odd / even)、:nth-last-child()、:first-child、:last-child、:not(复合选择器)
+//   - 组合：后代（空格）、子元素（>），以及用逗号分隔的多个选择器
+//
+// 在某个节点内查询时，组合关系只在该节点的子树内匹配
+type Selector struct {
+	source string
+	groups []complexSelector
+}
+
+// complexSelector 由组合符连接的复合选择器，如 div.list > span.price
+type complexSelector struct {
+	parts       []compoundSelector
+	combinators []byte // combinators[i] 连接 parts[i] 和 parts[i+1]：' ' 后代，'>' 子元素
+}
+
+// compoundSelector 作用于单个元素的条件，如 span.price[data-id]
+type compoundSelector struct {
+	tag     string // 空表示任意元素
+	id      string
+	classes []string
+	attrs   []attrMatcher
+	pseudos []pseudoMatcher
+}
+
+// attrMatcher 属性条件
+type attrMatcher struct {
+	key   string
+	op    string // 空表示只要求属性存在
+	value string
+}
+
+// pseudoMatcher 伪类条件
+type pseudoMatcher struct {
+	name    string // nth-child / nth-last-child / not
+	a, b    int    // nth 参数 an+b
+	negated *compoundSelector
+}
+
+// compileSelector 解析选择器
+func compileSelector(source string) (*Selector, error) {
+	p := &selectorParser{source: source}
+	s := &Selector{source: source}
+	for {
+		p.skipSpaces()
+		complex, err := p.parseComplex()
+		if err != nil {
+			return nil, err
+		}
+		s.groups = append(s.groups, complex)
+		p.skipSpaces()
+		if p.done() {
+			return s, nil
+		}
+		if p.peek() != ',' {
+			return nil, p.errorf("无法识别的字符 %q", string(p.peek()))
+		}
+		p.pos++
+	}
+}
+
+// selectorParser 选择器解析器
+type selectorParser struct {
+	source string
+	pos    int
+}
+
+func (p *selectorParser) done() bool { return p.pos >= len(p.source) }
+
+func (p *selectorParser) peek() byte { return p.source[p.pos] }
+
+func (p *selectorParser) errorf(format string, args ...interface{}) error {
+	if strings.TrimSpace(p.source) == "" {
+		return fmt.Errorf("选择器为空")
+	}
+	return fmt.Errorf("选择器 %q 第%d个字符: %s", p.source, p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *selectorParser) skipSpaces() bool {
+	start := p.pos
+	for !p.done() && isSelectorSpace(p.peek()) {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+// parseComplex 解析由组合符连接的复合选择器
+func (p *selectorParser) parseComplex() (complexSelector, error) {
+	var c complexSelector
+	for {
+		compound, err := p.parseCompound()
+		if err != nil {
+			return c, err
+		}
+		c.parts = append(c.parts, compound)
+
+		spaced := p.skipSpaces()
+		if p.done() || p.peek() == ',' || p.peek() == ')' {
+			return c, nil
+		}
+		combinator := byte(' ')
+		if p.peek() == '>' {
+			combinator = '>'
+			p.pos++
+			p.skipSpaces()
+		} else if !spaced {
+			return c, p.errorf("无法识别的字符 %q", string(p.peek()))
+		}
+		if p.done() {
+			return c, p.errorf("组合符后缺少选择器")
+		}
+		c.combinators = append(c.combinators, combinator)
+	}
+}
+
+// parseCompound 解析复合选择器
+func (p *selectorParser) parseCompound() (compoundSelector, error) {
+	var c compoundSelector
+	start := p.pos
+
+	if !p.done() && p.peek() == '*' {
+		p.pos++
+	} else if name := p.readName(); name != "" {
+		c.tag = strings.ToLower(name)
+	}
+
+	for !p.done() {
+		switch p.peek() {
+		case '.':
+			p.pos++
+			class := p.readName()
+			if class == "" {
+				return c, p.errorf(". 后缺少类名")
+			}
+			c.classes = append(c.classes, class)
+		case '#':
+			p.pos++
+			id := p.readName()
+			if id == "" {
+				return c, p.errorf("# 后缺少id")
+			}
+			c.id = id
+		case '[':
+			m, err := p.parseAttr()
+			if err != nil {
+				return c, err
+			}
+			c.attrs = append(c.attrs, m)
+		case ':':
+			m, err := p.parsePseudo()
+			if err != nil {
+				return c, err
+			}
+			c.pseudos = append(c.pseudos, m)
+		default:
+			if p.pos == start {
+				return c, p.errorf("缺少选择器")
+			}
+			return c, nil
+		}
+	}
+	if p.pos == start {
+		return c, p.errorf("缺少选择器")
+	}
+	return c, nil
+}
+
+// parseAttr 解析 [attr op value]
+func (p *selectorParser) parseAttr() (attrMatcher, error) {
+	p.pos++ // [
+	p.skipSpaces()
+	key := p.readName()
+	if key == "" {
+		return attrMatcher{}, p.errorf("[ 后缺少属性名")
+	}
+	m := attrMatcher{key: strings.ToLower(key)}
+	p.skipSpaces()
+	if p.done() {
+		return m, p.errorf("[ 没有闭合")
+	}
+	if p.peek() == ']' {
+		p.pos++
+		return m, nil
+	}
+
+	for _, op := range []string{"=", "~=", "|=", "^=", "$=", "*="} {
+		if strings.HasPrefix(p.source[p.pos:], op) {
+			m.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if m.op == "" {
+		return m, p.errorf("不支持的属性运算符")
+	}
+
+	p.skipSpaces()
+	if p.done() {
+		return m, p.errorf("[ 没有闭合")
+	}
+	if quote := p.peek(); quote == '"' || quote == '\'' {
+		end := strings.IndexByte(p.source[p.pos+1:], quote)
+		if end < 0 {
+			return m, p.errorf("引号没有闭合")
+		}
+		m.value = p.source[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		p.skipSpaces()
+		if p.done() || p.peek() != ']' {
+			return m, p.errorf("[ 没有闭合")
+		}
+	} else {
+		// 未加引号的值读到 ] 为止，允许 font-size:11px; 这样的内容
+		end := strings.IndexByte(p.source[p.pos:], ']')
+		if end < 0 {
+			return m, p.errorf("[ 没有闭合")
+		}
+		m.value = strings.TrimSpace(p.source[p.pos : p.pos+end])
+		p.pos += end
+	}
+	p.pos++ // ]
+	return m, nil
+}
+
+// parsePseudo 解析伪类
+func (p *selectorParser) parsePseudo() (pseudoMatcher, error) {
+	p.pos++ // :
+	name := strings.ToLower(p.readName())
+	switch name {
+	case "first-child":
+		return pseudoMatcher{name: "nth-child", b: 1}, nil
+	case "last-child":
+		return pseudoMatcher{name: "nth-last-child", b: 1}, nil
+	case "nth-child", "nth-last-child", "not":
+	case "":
+		return pseudoMatcher{}, p.errorf(": 后缺少伪类名")
+	default:
+		return pseudoMatcher{}, p.errorf("不支持的伪类 :%s", name)
+	}
+
+	if p.done() || p.peek() != '(' {
+		return pseudoMatcher{}, p.errorf(":%s 缺少参数", name)
+	}
+	p.pos++
+	p.skipSpaces()
+
+	m := pseudoMatcher{name: name}
+	if name == "not" {
+		negated, err := p.parseCompound()
+		if err != nil {
+			return m, err
+		}
+		m.negated = &negated
+	} else {
+		end := strings.IndexByte(p.source[p.pos:], ')')
+		if end < 0 {
+			return m, p.errorf(":%s( 没有闭合", name)
+		}
+		a, b, err := parseNth(p.source[p.pos : p.pos+end])
+		if err != nil {
+			return m, p.errorf(":%s 的参数有误: %v", name, err)
+		}
+		m.a, m.b = a, b
+		p.pos += end
+	}
+
+	p.skipSpaces()
+	if p.done() || p.peek() != ')' {
+		return m, p.errorf(":%s( 没有闭合", name)
+	}
+	p.pos++
+	return m, nil
+}
+
+// parseNth 解析 an+b、odd、even
+func parseNth(expr string) (int, int, error) {
+	expr = strings.ToLower(strings.ReplaceAll(expr, " ", ""))
+	switch expr {
+	case "odd":
+		return 2, 1, nil
+	case "even":
+		return 2, 0, nil
+	case "":
+		return 0, 0, fmt.Errorf("参数为空")
+	}
+
+	i := strings.IndexByte(expr, 'n')
+	if i < 0 {
+		b, err := strconv.Atoi(expr)
+		if err != nil {
+			return 0, 0, fmt.Errorf("无效的参数 %q", expr)
+		}
+		return 0, b, nil
+	}
+
+	var a int
+	switch coef := expr[:i]; coef {
+	case "", "+":
+		a = 1
+	case "-":
+		a = -1
+	default:
+		n, err := strconv.Atoi(coef)
+		if err != nil {
+			return 0, 0, fmt.Errorf("无效的参数 %q", expr)
+		}
+		a = n
+	}
+
+	var b int
+	if rest := expr[i+1:]; rest != "" {
+		if rest[0] != '+' && rest[0] != '-' {
+			return 0, 0, fmt.Errorf("无效的参数 %q", expr)
+		}
+		n, err := strconv.Atoi(rest)
+		if err != nil {
+			return 0, 0, fmt.Errorf("无效的参数 %q", expr)
+		}
+		b = n
+	}
+	return a, b, nil
+}
+
+// readName 读取标签名、类名、id或属性名
+func (p *selectorParser) readName() string {
+	start := p.pos
+	for !p.done() && isSelectorNameChar(p.peek()) {
+		p.pos++
+	}
+	return p.source[start:p.pos]
+}
+
+// isSelectorNameChar 标签名、类名、id中允许的字符
+func isSelectorNameChar(c byte) bool {
+	return c == '-' || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isSelectorSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// String 选择器原文
+func (s *Selector) String() string {
+	return s.source
+}
+
+// Match 判断节点是否满足选择器，组合关系可以匹配到文档根节点
+func (s *Selector) Match(n *html.Node) bool {
+	return s.matchWithin(n, nil)
+}
+
+// matchWithin 判断节点是否满足选择器，祖先节点只在 scope（不含）以下查找
+func (s *Selector) matchWithin(n *html.Node, scope *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	for _, c := range s.groups {
+		if c.match(n, len(c.parts)-1, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// match 从右向左匹配：parts[i] 匹配节点 n，再按组合符向上匹配 parts[i-1]
+func (c complexSelector) match(n *html.Node, i int, scope *html.Node) bool {
+	if !c.parts[i].match(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	switch c.combinators[i-1] {
+	case '>':
+		parent := n.Parent
+		return parent != nil && parent != scope && parent.Type == html.ElementNode && c.match(parent, i-1, scope)
+	default:
+		for parent := n.Parent; parent != nil && parent != scope; parent = parent.Parent {
+			if parent.Type == html.ElementNode && c.match(parent, i-1, scope) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// match 判断单个元素是否满足复合选择器
+func (c *compoundSelector) match(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if c.tag != "" && n.Data != c.tag {
+		return false
+	}
+	if c.id != "" && nodeAttr(n, "id") != c.id {
+		return false
+	}
+	if len(c.classes) > 0 {
+		classes := strings.Fields(nodeAttr(n, "class"))
+		for _, want := range c.classes {
+			if !containsString(classes, want) {
+				return false
+			}
+		}
+	}
+	for _, m := range c.attrs {
+		if !m.match(n) {
+			return false
+		}
+	}
+	for _, m := range c.pseudos {
+		if !m.match(n) {
+			return false
+		}
+	}
+	return true
+}
+
+// match 判断属性条件
+func (m attrMatcher) match(n *html.Node) bool {
+	value, ok := lookupAttr(n, m.key)
+	if !ok {
+		return false
+	}
+	switch m.op {
+	case "":
+		return true
+	case "=":
+		return value == m.value
+	case "~=":
+		return containsString(strings.Fields(value), m.value)
+	case "|=":
+		return value == m.value || strings.HasPrefix(value, m.value+"-")
+	case "^=":
+		return m.value != "" && strings.HasPrefix(value, m.value)
+	case "$=":
+		return m.value != "" && strings.HasSuffix(value, m.value)
+	case "*=":
+		return m.value != "" && strings.Contains(value, m.value)
+	}
+	return false
+}
+
+// match 判断伪类条件
+func (m pseudoMatcher) match(n *html.Node) bool {
+	switch m.name {
+	case "not":
+		return !m.negated.match(n)
+	case "nth-child":
+		return nthMatches(m.a, m.b, elementIndex(n, false))
+	case "nth-last-child":
+		return nthMatches(m.a, m.b, elementIndex(n, true))
+	}
+	return false
+}
+
+// elementIndex 节点在兄弟元素中的位置，从1开始；fromEnd 为 true 时从最后一个开始计数
+func elementIndex(n *html.Node, fromEnd bool) int {
+	index := 1
+	next := func(s *html.Node) *html.Node { return s.PrevSibling }
+	if fromEnd {
+		next = func(s *html.Node) *html.Node { return s.NextSibling }
+	}
+	for s := next(n); s != nil; s = next(s) {
+		if s.Type == html.ElementNode {
+			index++
+		}
+	}
+	return index
+}
+
+// nthMatches 判断位置是否满足 an+b（n 取非负整数）
+func nthMatches(a, b, index int) bool {
+	if a == 0 {
+		return index == b
+	}
+	diff := index - b
+	return diff%a == 0 && diff/a >= 0
+}
+
+// QueryAll 返回 root 的所有后代中满足选择器的节点，按文档顺序
+func (s *Selector) QueryAll(root *html.Node) []*html.Node {
+	var result []*html.Node
+	var traverse func(*html.Node)
+	traverse = func(node *html.Node) {
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			if s.matchWithin(c, root) {
+				result = append(result, c)
+			}
+			traverse(c)
+		}
+	}
+	traverse(root)
+	return result
+}
+
+// Query 返回第一个满足选择器的后代节点，没有时返回nil
+func (s *Selector) Query(root *html.Node) *html.Node {
+	var found *html.Node
+	var traverse func(*html.Node) bool
+	traverse = func(node *html.Node) bool {
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			if s.matchWithin(c, root) {
+				found = c
+				return true
+			}
+			if traverse(c) {
+				return true
+			}
+		}
+		return false
+	}
+	traverse(root)
+	return found
+}
+
+// lookupAttr 获取节点属性
+func lookupAttr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// nodeAttr 获取节点属性，不存在时返回空字符串
+func nodeAttr(n *html.Node, key string) string {
+	value, _ := lookupAttr(n, key)
+	return value
+}
+
+// containsString 切片中是否包含指定字符串
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
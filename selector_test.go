@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// TestSelectorConfig 测试配置中的解析规则覆盖内置规则，以及规则错误的定位
+func TestSelectorConfig(t *testing.T) {
+	dir := t.TempDir()
+	load := func(selectors string) (*Config, error) {
+		path := filepath.Join(dir, "config.json")
+		content := `{"cookie": "a=b", "categories": [{"id": "leaf", "name": "叶菜类"}], "selectors": ` + selectors + `}`
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return LoadConfig(path)
+	}
+
+	invalid := []struct {
+		selectors string
+		where     string
+	}{
+		{`{"fengzhansy": {"fields": {"price": [{"selector": "span.price"}, {"selector": "em", "regex": "([0-9"}]}}}`, "selectors.fengzhansy.fields.price[1].regex"},
+		{`{"fengzhansy": {"fields": {"name": [{"selector": "span[class"}]}}}`, "selectors.fengzhansy.fields.name[0].selector"},
+		{`{"fengzhansy": {"fields": {"spec": [{"builtin": "weight"}]}}}`, "selectors.fengzhansy.fields.spec[0].builtin"},
+		{`{"fengzhansy": {"fields": {"spec": [{}]}}}`, "selectors.fengzhansy.fields.spec[0]"},
+		{`{"fengzhansy": {"fields": {"stock": [{"selector": "em"}]}}}`, "selectors.fengzhansy.fields.stock"},
+		{`{"fengzhansy": {"container": "div..list"}}`, "selectors.fengzhansy.container"},
+		{`{"fengzhansy": {"detail": {"weight": [{"selector": "em"}]}}}`, "selectors.fengzhansy.detail.weight"},
+		{`{"fengzhansy": {"detail": {"origin": [{"selector": "p:hover"}]}}}`, "selectors.fengzhansy.detail.origin[0].selector"},
+		{`{"unknown": {}}`, "selectors.unknown"},
+	}
+	for _, tc := range invalid {
+		_, err := load(tc.selectors)
+		if err == nil || !strings.HasPrefix(err.Error(), tc.where+":") {
+			t.Errorf("selectors %s 的错误 = %v, 期望指向 %s", tc.selectors, err, tc.where)
+		}
+	}
+
+	// 只覆盖名称和价格规则，规格仍使用内置规则
+	config, err := load(`{"fengzhansy": {
+		"container": "ul#goods",
+		"item": "li",
+		"fields": {
+			"name": [{"selector": "p.title"}],
+			"price": [{"selector": "p", "attr": "data-price"}, {"selector": "em", "regex": "售价\\s*([0-9.]+)"}]
+		}
+	}}`)
+	if err != nil {
+		t.Fatalf("LoadConfig 返回错误: %v", err)
+	}
+	page := `<ul id="goods">
+<li><a href="/p/1"></a><p class="title">西红柿</p><p data-price="3.98">￥3.98</p><span class="spec">500g</span></li>
+<li><a href="/p/2"></a><p class="title">土豆</p><em>售价 1.50</em><span class="spec">1斤</span></li>
+</ul>`
+	adapter, _ := getAdapter(DefaultAdapter)
+	products, err := adapter.Parse(context.Background(), *config, page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Product{
+		{ID: "/p/1", Name: "西红柿", Price: 3.98, Spec: "500g", PricePerJin: 3.98, PricePerGram: 3.98 / 500, Unit: "元/斤"},
+		{ID: "/p/2", Name: "土豆", Price: 1.50, Spec: "1斤", PricePerJin: 1.50, PricePerGram: 1.50 / 500, Unit: "元/斤"},
+	}
+	if fmt.Sprint(products) != fmt.Sprint(want) {
+		t.Errorf("解析结果 = %+v, 期望 %+v", products, want)
+	}
+
+	// 内置规则找不到容器时报告使用的选择器
+	if _, err := parseHTML(context.Background(), page); err == nil || !strings.Contains(err.Error(), "index_picAD") {
+		t.Errorf("内置规则解析自定义页面应报告找不到容器, err=%v", err)
+	}
+}
+
+// fengzhansyListPage 按凤展超市分类页面（叶菜类）整理的商品列表，包括划线原价、类名不规范的价格和规格等情况
+const fengzhansyListPage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>叶菜类</title></head>
+<body>
+<div class="header"><span class="price">￥0.00</span><h3>购物车</h3></div>
+<div class="index_picAD clearfix" id="goodsList">
+  <div class="pic_item" data-spid="1001">
+    <a href="wap.shtml?method=spxq&amp;spid=1001"><img src="/upload/1001.jpg" alt="菠菜"></a>
+    <h3>菠菜</h3>
+    <p><span class="price">￥3.98</span> <del class="price_old">￥4.98</del></p>
+    <span style="font-size:11px;color:#999">500g</span>
+  </div>
+  <div class="pic_item hot" data-spid="1002">
+    <a href="wap.shtml?method=spxq&amp;spid=1002"><img src="/upload/1002.jpg" alt="小白菜"></a>
+    <h3>小白菜 约1斤</h3>
+    <p><del class="old_price">￥3.50</del> <span class="goods_price">￥2.50</span></p>
+    <span class="goods_spec">1斤</span>
+  </div>
+  <div class="pic_item" data-spid="1003">
+    <a href="wap.shtml?method=spxq&amp;spid=1003"><img src="/upload/1003.jpg" alt="生菜"></a>
+    <h3>生菜</h3>
+    <p><span class="price vip">￥2.00</span></p>
+    <span class="spec">250g</span>
+  </div>
+  <div class="pic_item sold_out" data-spid="1004">
+    <a href="wap.shtml?method=spxq&amp;spid=1004"><img src="/upload/1004.jpg" alt="香菜"></a>
+    <h3>香菜</h3>
+    <p><span class="price">￥1.50</span></p>
+    <span style="font-size:11px;color:#999">250g</span>
+  </div>
+</div>
+</body></html>`
+
+// TestSelector 测试选择器在凤展超市页面上的匹配结果
+func TestSelector(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(fengzhansyListPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID, _ := compileSelector("#goodsList")
+	list := byID.Query(doc)
+
+	tests := []struct {
+		selector string
+		root     *html.Node
+		want     string // 匹配到的节点文本，用 | 连接
+	}{
+		{"h3", doc, "购物车|菠菜|小白菜 约1斤|生菜|香菜"},
+		{"div.index_picAD h3", doc, "菠菜|小白菜 约1斤|生菜|香菜"},
+		{"body > div > h3", doc, "购物车"},
+		{"body > h3", doc, ""},
+		{".index_picAD > .pic_item > h3", doc, "菠菜|小白菜 约1斤|生菜|香菜"},
+		// 类名按完整单词匹配，goods_price 和 price_old 不算 price
+		{"div.index_picAD .price", doc, "￥3.98|￥2.00|￥1.50"},
+		{"span.price.vip", doc, "￥2.00"},
+		{"[class*=price]", list, "￥3.98|￥4.98|￥3.50|￥2.50|￥2.00|￥1.50"},
+		{"[class*=price]:not([class*=old])", list, "￥3.98|￥2.50|￥2.00|￥1.50"},
+		{"[class~=vip]", list, "￥2.00"},
+		{"[class=price]", list, "￥3.98|￥1.50"},
+		{"[class^=price]", list, "￥3.98|￥4.98|￥2.00|￥1.50"},
+		{"[class$=_price]", list, "￥3.50|￥2.50"},
+		{"[class|=price]", list, "￥3.98|￥1.50"},
+		{`span[style*="font-size:11px"]`, list, "500g|250g"},
+		{"span[style*=font-size:11px;]", list, "500g|250g"},
+		{`img[alt='生菜']`, list, ""},
+		{"div[data-spid='1003'] span", list, "￥2.00|250g"},
+		// :nth-child 只计算元素节点，不计文本和注释
+		{"div.pic_item:nth-child(2) > h3", list, "小白菜 约1斤"},
+		{"div.pic_item:nth-child(odd) > h3", list, "菠菜|生菜"},
+		{"div.pic_item:nth-child(even) > h3", list, "小白菜 约1斤|香菜"},
+		{"div.pic_item:nth-child(n+3) > h3", list, "生菜|香菜"},
+		{"div.pic_item:nth-child(-n+2) > h3", list, "菠菜|小白菜 约1斤"},
+		{"div.pic_item:nth-child( 2n + 2 ) > h3", list, "小白菜 约1斤|香菜"},
+		{"div:first-child > h3", list, "菠菜"},
+		{"div:last-child > h3", list, "香菜"},
+		{"div:nth-last-child(2) > h3", list, "生菜"},
+		{"div:not(.hot):not(.sold_out) > h3", list, "菠菜|生菜"},
+		// 逗号分隔时按文档顺序返回
+		{"span.spec, h3", list, "菠菜|小白菜 约1斤|生菜|250g|香菜"},
+		// 组合关系只在查询的节点内匹配
+		{"div h3", list, "菠菜|小白菜 约1斤|生菜|香菜"},
+		{"body h3", list, ""},
+	}
+	for _, tc := range tests {
+		s, err := compileSelector(tc.selector)
+		if err != nil {
+			t.Errorf("compileSelector(%q) 返回错误: %v", tc.selector, err)
+			continue
+		}
+		var texts []string
+		for _, n := range s.QueryAll(tc.root) {
+			texts = append(texts, strings.TrimSpace(getTextContent(n)))
+		}
+		if got := strings.Join(texts, "|"); got != tc.want {
+			t.Errorf("%q 匹配 %q, 期望 %q", tc.selector, got, tc.want)
+		}
+	}
+
+	invalid := []string{"", " ", "div..list", "div >", "> div", "div,", "span[class", "span[class^]", "span[class=\"price]",
+		"li:hover", "li:nth-child(x)", "li:nth-child(2", "li:not(div span)", "div#", "span+em"}
+	for _, source := range invalid {
+		if _, err := compileSelector(source); err == nil {
+			t.Errorf("compileSelector(%q) 应返回错误", source)
+		}
+	}
+}
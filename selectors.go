@@ -0,0 +1,321 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// 商品字段
+const (
+	FieldID            = "id"
+	FieldName          = "name"
+	FieldPrice         = "price"          // 价格文本，其中的原价、会员价、促销标签也会被识别
+	FieldOriginalPrice = "original_price" // 原价（划线价）
+	FieldMemberPrice   = "member_price"   // 会员价
+	FieldPromo         = "promo"          // 促销标签
+	FieldSpec          = "spec"
+)
+
+// productFields 按提取顺序排列的商品字段，spec_from_name 依赖先提取的名称
+var productFields = []string{FieldID, FieldName, FieldPrice, FieldOriginalPrice, FieldMemberPrice, FieldPromo, FieldSpec}
+
+// 商品详情页字段
+const (
+	DetailImages        = "images"         // 商品图片，取所有匹配元素的值
+	DetailOrigin        = "origin"         // 产地
+	DetailDescription   = "description"    // 商品描述
+	DetailPrice         = "price"          // 当前售价（促销价）
+	DetailOriginalPrice = "original_price" // 原价（划线价）
+	DetailMemberPrice   = "member_price"   // 会员价
+	DetailStock         = "stock"          // 库存文本，如 库存12、已售完
+)
+
+// detailFields 详情页支持的字段
+var detailFields = []string{DetailImages, DetailOrigin, DetailDescription, DetailPrice, DetailOriginalPrice, DetailMemberPrice, DetailStock}
+
+// 内置的提取方法
+const (
+	BuiltinPriceText    = "price_text"     // 查找包含 元/￥ 和数字的文本
+	BuiltinSpecText     = "spec_text"      // 查找包含重量单位和数字的文本
+	BuiltinSpecFromName = "spec_from_name" // 从商品名称中提取规格
+)
+
+// SelectorRule 单个字段的一条提取规则，同一字段的多条规则按顺序尝试，取第一个非空结果
+type SelectorRule struct {
+	Selector string `json:"selector,omitempty"` // 在商品节点内查找的CSS选择器，为空时使用商品节点本身
+	Attr     string `json:"attr,omitempty"`     // 读取的属性，为空时读取文本
+	Regex    string `json:"regex,omitempty"`    // 从读取的值中提取，有分组时取第一个分组
+	Builtin  string `json:"builtin,omitempty"`  // 内置提取方法，不能与 selector 同时使用
+}
+
+// SelectorConfig 商品列表页面的解析规则
+type SelectorConfig struct {
+	Container string                    `json:"container,omitempty"` // 商品列表容器
+	Item      string                    `json:"item,omitempty"`      // 容器的直接子元素中代表一个商品的元素
+	Fields    map[string][]SelectorRule `json:"fields,omitempty"`    // 各字段的提取规则：id / name / price / spec
+	Detail    map[string][]SelectorRule `json:"detail,omitempty"`    // 商品详情页各字段的提取规则，在整个页面中查找
+}
+
+// selectorAdapter 可选接口，使用可配置解析规则的适配器提供内置规则
+type selectorAdapter interface {
+	DefaultSelectors() SelectorConfig
+}
+
+// productSelectors 编译后的解析规则
+type productSelectors struct {
+	container *Selector
+	item      *Selector
+	fields    map[string][]compiledRule
+	detail    map[string][]compiledRule
+}
+
+// compiledRule 编译后的提取规则
+type compiledRule struct {
+	SelectorRule
+	selector *Selector
+	regex    *regexp.Regexp
+}
+
+// compileSelectors 合并内置规则和配置中的规则并编译。配置中出现的字段整体替换内置规则，
+// 错误信息中的 path 指向出错的规则，如 selectors.fengzhansy.fields.price[1].regex
+func compileSelectors(defaults, override SelectorConfig, path string) (*productSelectors, error) {
+	merged := defaults
+	if override.Container != "" {
+		merged.Container = override.Container
+	}
+	if override.Item != "" {
+		merged.Item = override.Item
+	}
+	merged.Fields = mergeRules(defaults.Fields, override.Fields)
+	merged.Detail = mergeRules(defaults.Detail, override.Detail)
+
+	compiled := &productSelectors{}
+	var err error
+	if compiled.container, err = compileSelector(merged.Container); err != nil {
+		return nil, fmt.Errorf("%s.container: %v", path, err)
+	}
+	if compiled.item, err = compileSelector(merged.Item); err != nil {
+		return nil, fmt.Errorf("%s.item: %v", path, err)
+	}
+	if compiled.fields, err = compileFieldRules(merged.Fields, productFields, path+".fields"); err != nil {
+		return nil, err
+	}
+	if compiled.detail, err = compileFieldRules(merged.Detail, detailFields, path+".detail"); err != nil {
+		return nil, err
+	}
+	return compiled, nil
+}
+
+// mergeRules 合并字段规则，override 中出现的字段整体替换 defaults
+func mergeRules(defaults, override map[string][]SelectorRule) map[string][]SelectorRule {
+	merged := make(map[string][]SelectorRule, len(defaults)+len(override))
+	for field, rules := range defaults {
+		merged[field] = rules
+	}
+	for field, rules := range override {
+		merged[field] = rules
+	}
+	return merged
+}
+
+// compileFieldRules 编译各字段的规则，allowed 为支持的字段
+func compileFieldRules(rules map[string][]SelectorRule, allowed []string, path string) (map[string][]compiledRule, error) {
+	// 按字段名排序，保证同时有多处错误时报告的位置稳定
+	fields := make([]string, 0, len(rules))
+	for field := range rules {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	compiled := make(map[string][]compiledRule, len(rules))
+	for _, field := range fields {
+		if !containsString(allowed, field) {
+			return nil, fmt.Errorf("%s.%s: 未知的字段，支持 %s", path, field, strings.Join(allowed, " / "))
+		}
+		if len(rules[field]) == 0 {
+			return nil, fmt.Errorf("%s.%s: 至少需要一条规则", path, field)
+		}
+		for i, rule := range rules[field] {
+			c, err := compileRule(rule)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s[%d]%v", path, field, i, err)
+			}
+			compiled[field] = append(compiled[field], c)
+		}
+	}
+	return compiled, nil
+}
+
+// compileRule 编译单条规则，返回的错误以出错的属性开头，如 ".regex: ..."
+func compileRule(rule SelectorRule) (compiledRule, error) {
+	c := compiledRule{SelectorRule: rule}
+	switch rule.Builtin {
+	case "":
+	case BuiltinPriceText, BuiltinSpecText, BuiltinSpecFromName:
+		if rule.Selector != "" || rule.Attr != "" {
+			return c, fmt.Errorf(".builtin: 内置方法 %s 不能与 selector / attr 同时使用", rule.Builtin)
+		}
+	default:
+		return c, fmt.Errorf(".builtin: 未知的内置方法 %q，支持 %s、%s、%s", rule.Builtin, BuiltinPriceText, BuiltinSpecText, BuiltinSpecFromName)
+	}
+
+	if rule.Selector != "" {
+		selector, err := compileSelector(rule.Selector)
+		if err != nil {
+			return c, fmt.Errorf(".selector: %v", err)
+		}
+		c.selector = selector
+	}
+	if rule.Regex != "" {
+		regex, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return c, fmt.Errorf(".regex: 无效的正则表达式 %q: %v", rule.Regex, err)
+		}
+		c.regex = regex
+	}
+	if rule.Builtin == "" && rule.Selector == "" && rule.Attr == "" && rule.Regex == "" {
+		return c, fmt.Errorf(": 规则为空，需要 selector、attr、regex 或 builtin")
+	}
+	return c, nil
+}
+
+// selectorsFor 返回适配器的解析规则，配置了 selectors.<adapter> 时与内置规则合并
+func selectorsFor(config Config, adapter string, defaults SelectorConfig) (*productSelectors, error) {
+	return compileSelectors(defaults, config.Selectors[adapter], "selectors."+adapter)
+}
+
+// validateSelectors 检查配置中的解析规则
+func (c *Config) validateSelectors() error {
+	names := make([]string, 0, len(c.Selectors))
+	for name := range c.Selectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		adapter, err := getAdapter(name)
+		if err != nil {
+			return fmt.Errorf("selectors.%s: %v", name, err)
+		}
+		sa, ok := adapter.(selectorAdapter)
+		if !ok {
+			return fmt.Errorf("selectors.%s: 该网站适配器不支持配置解析规则", name)
+		}
+		if _, err := selectorsFor(*c, name, sa.DefaultSelectors()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extract 按规则从商品节点中提取字段值，fields 为已提取的字段
+func (r compiledRule) extract(item *html.Node, fields map[string]string) string {
+	if values := r.extractValues(item, fields, false); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// extractValues 提取非空的值，all 为 false 时只取第一个
+func (r compiledRule) extractValues(item *html.Node, fields map[string]string, all bool) []string {
+	if r.Builtin != "" {
+		var value string
+		switch r.Builtin {
+		case BuiltinPriceText:
+			value = r.apply(findPriceText(item))
+		case BuiltinSpecText:
+			value = r.apply(findSpecText(item))
+		case BuiltinSpecFromName:
+			value = r.apply(extractSpecFromName(fields[FieldName]))
+		}
+		if value == "" {
+			return nil
+		}
+		return []string{value}
+	}
+
+	var values []string
+	nodes := []*html.Node{item}
+	if r.selector != nil {
+		nodes = r.selector.QueryAll(item)
+	}
+	for _, node := range nodes {
+		var value string
+		if r.Attr != "" {
+			value = nodeAttr(node, r.Attr)
+		} else {
+			value = getTextContent(node)
+		}
+		if value = r.apply(value); value != "" {
+			values = append(values, value)
+			if !all {
+				break
+			}
+		}
+	}
+	return values
+}
+
+// apply 对读取到的值执行正则提取
+func (r compiledRule) apply(value string) string {
+	value = strings.TrimSpace(value)
+	if r.regex == nil || value == "" {
+		return value
+	}
+	m := r.regex.FindStringSubmatch(value)
+	switch {
+	case m == nil:
+		return ""
+	case len(m) > 1:
+		return strings.TrimSpace(m[1])
+	default:
+		return strings.TrimSpace(m[0])
+	}
+}
+
+// extractFields 按顺序提取商品节点的所有字段
+func (s *productSelectors) extractFields(item *html.Node) map[string]string {
+	fields := make(map[string]string, len(productFields))
+	for _, field := range productFields {
+		for _, rule := range s.fields[field] {
+			if value := rule.extract(item, fields); value != "" {
+				fields[field] = value
+				break
+			}
+		}
+	}
+	return fields
+}
+
+// extractDetail 提取详情页的所有字段，图片取第一条有结果的规则匹配到的所有值，其余字段只有一个值
+func (s *productSelectors) extractDetail(doc *html.Node) map[string][]string {
+	fields := make(map[string][]string, len(detailFields))
+	for _, field := range detailFields {
+		for _, rule := range s.detail[field] {
+			values := rule.extractValues(doc, nil, field == DetailImages)
+			if len(values) > 0 {
+				fields[field] = values
+				break
+			}
+		}
+	}
+	return fields
+}
+
+// items 返回页面中所有商品节点
+func (s *productSelectors) items(doc *html.Node) ([]*html.Node, error) {
+	container := s.container.Query(doc)
+	if container == nil {
+		return nil, fmt.Errorf("未找到商品容器 %s", s.container)
+	}
+	var items []*html.Node
+	for child := container.FirstChild; child != nil; child = child.NextSibling {
+		if s.item.Match(child) {
+			items = append(items, child)
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,518 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// shutdownTimeout 收到退出信号后等待进行中请求完成的最长时间
+const shutdownTimeout = 15 * time.Second
+
+// runServeMode 以独立HTTP服务方式运行
+func runServeMode(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", "", "监听地址，默认读取配置文件中的 listen_addr")
+	flags.Parse(args)
+
+	// 配置只在启动时加载一次，修改配置后需要重启服务
+	config, err := LoadConfig("config.json")
+	if err != nil {
+		fmt.Printf("加载配置文件失败: %v\n", err)
+		os.Exit(1)
+	}
+	if *addr == "" {
+		*addr = config.ListenAddr
+	}
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           logRequests(newServeMux(config)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		fmt.Printf("HTTP服务已启动: %s\n", *addr)
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("HTTP服务启动失败: %v\n", err)
+			os.Exit(1)
+		}
+	case <-ctx.Done():
+		fmt.Println("收到退出信号，正在关闭HTTP服务...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			fmt.Printf("关闭HTTP服务失败: %v\n", err)
+		}
+		fmt.Println("HTTP服务已关闭")
+	}
+}
+
+// configHandler 使用启动时加载的配置处理请求的函数
+type configHandler func(w http.ResponseWriter, r *http.Request, config *Config)
+
+// newServeMux 注册所有路由，所有请求使用同一份配置
+func newServeMux(config *Config) *http.ServeMux {
+	mux := http.NewServeMux()
+	handle := func(pattern string, handler configHandler) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			handler(w, r, config)
+		})
+	}
+	handle("GET /{$}", handleIndex)
+	handle("GET /api/categories", handleCategories)
+	handle("GET /api/categories/{id}", handleCategory)
+	handle("GET /api/products/{id...}", handleProduct)
+	handle("GET /api/diff", handleDiff)
+	handle("GET /api/stores", handleStores)
+	handle("GET /api/compare", handleCompare)
+	handle("GET /api/shopping", handleShopping)
+	handle("POST /api/shopping", handleShopping)
+	handle("GET /api/watchlists/{id}", handleWatchlist)
+	handle("DELETE /api/watchlists/{id}", handleClearWatchlist)
+	handle("POST /api/watchlists/{id}/products", handleWatchProduct)
+	handle("DELETE /api/watchlists/{id}/products/{product...}", handleUnwatchProduct)
+	handle("POST /api/watchlists/{id}/import", handleImportWatchlist)
+	handle("GET /debug", handleDebug)
+	mux.HandleFunc("GET /healthz", handleHealth)
+	// 页面使用的CSS和字体等静态文件
+	mux.Handle("GET /static/", staticHandler(config.TemplateDir))
+	return mux
+}
+
+// logRequests 打印请求日志
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		fmt.Printf("%s %s %v\n", r.Method, r.URL.RequestURI(), time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// writeFunctionResponse 将阿里云函数响应写回HTTP响应
+func writeFunctionResponse(w http.ResponseWriter, resp AliyunFunctionResponse) {
+	for key, value := range resp.Headers {
+		w.Header().Set(key, value)
+	}
+	w.WriteHeader(resp.StatusCode)
+	w.Write([]byte(resp.Body))
+}
+
+// writeJSON 输出JSON响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONError 输出JSON错误
+func writeJSONError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	writeJSON(w, status, map[string]string{"error": fmt.Sprintf(format, args...)})
+}
+
+// watchlistCookie 保存收藏夹ID的Cookie，在一台设备上用 ?watchlist= 打开一次页面后，之后的访问都使用该收藏夹
+const watchlistCookie = "watchlist"
+
+// fetchStores 抓取所选门店的分类，有新抓取的分类时在后台检查一次价格提醒
+func fetchStores(ctx context.Context, config Config, stores []StoreConfig) PageData {
+	data := fetchStoresConcurrently(ctx, config, stores)
+	notifyAlertsAsync(config, data.Categories)
+	return data
+}
+
+// handleIndex 商品列表页面，unit 参数指定价格单位，store 参数指定门店，
+// watchlist 参数指定收藏夹ID，没有时使用Cookie中的收藏夹ID；
+// format 参数或 Accept 请求头为JSON、CSV时输出同样的数据
+func handleIndex(w http.ResponseWriter, r *http.Request, config *Config) {
+	query := r.URL.Query()
+	watchlist := query.Get("watchlist")
+	if cookie, err := r.Cookie(watchlistCookie); err == nil && watchlist == "" {
+		watchlist = cookie.Value
+	}
+	resp, err := HandleHttpRequestWithHtml(r.Context(), AliyunFunctionRequest{
+		Mode:      "normal",
+		Unit:      query.Get("unit"),
+		Store:     query.Get("store"),
+		List:      query.Get("list"),
+		Watchlist: watchlist,
+		Format:    query.Get("format"),
+		Accept:    r.Header.Get("Accept"),
+		serve:     true,
+		config:    config,
+	})
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "生成页面失败: %v", err)
+		return
+	}
+	if query.Get("watchlist") != "" && resp.StatusCode == http.StatusOK {
+		http.SetCookie(w, &http.Cookie{
+			Name:     watchlistCookie,
+			Value:    watchlist,
+			Path:     "/",
+			MaxAge:   365 * 24 * 3600,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	writeFunctionResponse(w, resp)
+}
+
+// handleCategories 所有分类及商品，unit 参数指定 price_unit，商品中的每克价格不受影响；
+// store 参数指定门店，多个用逗号分隔，all 为所有门店
+func handleCategories(w http.ResponseWriter, r *http.Request, config *Config) {
+	unit, err := priceUnitFor(*config, r.URL.Query().Get("unit"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	stores, err := config.selectStores(r.URL.Query().Get("store"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	data := fetchStores(r.Context(), *config, stores)
+	data.PriceUnit = unit
+	w.Header().Set("Age", strconv.FormatInt(data.maxCacheAge(), 10))
+	writeJSON(w, http.StatusOK, data)
+}
+
+// handleCategory 单个分类，只抓取该分类的页面，store 参数指定一个门店
+func handleCategory(w http.ResponseWriter, r *http.Request, config *Config) {
+	stores, err := config.selectStores(r.URL.Query().Get("store"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if len(stores) > 1 {
+		writeJSONError(w, http.StatusBadRequest, "只能选择一个门店")
+		return
+	}
+
+	id := r.PathValue("id")
+	for _, c := range storeCategories(*config, stores) {
+		if c.ID == id {
+			ctx, cancel := categoryContext(r.Context())
+			defer cancel()
+			category := productCache.Get(ctx, *config, c)
+			notifyAlertsAsync(*config, []Category{category})
+			w.Header().Set("Age", strconv.FormatInt(category.CacheAgeSeconds, 10))
+			writeJSON(w, http.StatusOK, category)
+			return
+		}
+	}
+	writeJSONError(w, http.StatusNotFound, "分类不存在: %s", id)
+}
+
+// handleProduct 按商品ID查找商品，store 参数同 /api/categories
+func handleProduct(w http.ResponseWriter, r *http.Request, config *Config) {
+	stores, err := config.selectStores(r.URL.Query().Get("store"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	id := r.PathValue("id")
+	data := fetchStores(r.Context(), *config, stores)
+	for _, category := range data.Categories {
+		for _, product := range category.Products {
+			if product.ID == id {
+				writeJSON(w, http.StatusOK, map[string]interface{}{
+					"category_id":   category.ID,
+					"category_name": category.Name,
+					"product":       product,
+				})
+				return
+			}
+		}
+	}
+	writeJSONError(w, http.StatusNotFound, "商品不存在: %s", id)
+}
+
+// handleDiff 当前价格与历史的对比，since、store 参数同 diff 命令
+func handleDiff(w http.ResponseWriter, r *http.Request, config *Config) {
+	baselineAt, err := parseBaseline(r.URL.Query().Get("since"), time.Now())
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	stores, err := config.selectStores(r.URL.Query().Get("store"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	store, err := getHistoryStore(config.History)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "打开历史存储失败: %v", err)
+		return
+	}
+	if store == nil {
+		writeJSONError(w, http.StatusNotImplemented, "未启用价格历史")
+		return
+	}
+
+	data := fetchStores(r.Context(), *config, stores)
+	diff, err := diffAgainstHistory(store, data, baselineAt)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "计算价格变化失败: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, diff)
+}
+
+// handleCompare 跨门店比价，store 参数默认为所有门店，q 参数按名称筛选
+func handleCompare(w http.ResponseWriter, r *http.Request, config *Config) {
+	query := r.URL.Query()
+	param := query.Get("store")
+	if param == "" {
+		param = AllStores
+	}
+	stores, err := config.selectStores(param)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	data := fetchStores(r.Context(), *config, stores)
+	w.Header().Set("Age", strconv.FormatInt(data.maxCacheAge(), 10))
+	writeJSON(w, http.StatusOK, comparePrices(data, query.Get("q")))
+}
+
+// shoppingRequest 购物清单请求，也可以用表单或查询参数 list、store 提交
+type shoppingRequest struct {
+	List  string `json:"list"`  // 购物清单，如 西红柿 2斤, 土豆 3斤, 香菜 1把
+	Store string `json:"store"` // 门店，同 /api/categories 的 store 参数
+}
+
+// handleShopping 计算购物清单的花费
+func handleShopping(w http.ResponseWriter, r *http.Request, config *Config) {
+
+	var req shoppingRequest
+	if !readRequest(w, r, &req, maxShoppingRequestSize) {
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		req.List, req.Store = r.FormValue("list"), r.FormValue("store")
+	}
+	items := parseShoppingList(*config, req.List)
+	if len(items) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "购物清单为空")
+		return
+	}
+	if len(items) > maxShoppingItems {
+		writeJSONError(w, http.StatusBadRequest, "购物清单最多%d项", maxShoppingItems)
+		return
+	}
+	stores, err := config.selectStores(req.Store)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	data := fetchStores(r.Context(), *config, stores)
+	w.Header().Set("Age", strconv.FormatInt(data.maxCacheAge(), 10))
+	writeJSON(w, http.StatusOK, calculateShoppingList(*config, data, items))
+}
+
+// watchRequest 收藏商品的请求，也可以用表单提交 product_id、store
+type watchRequest struct {
+	ProductID string `json:"product_id"`
+	Store     string `json:"store"` // 收藏时所在的门店，同 /api/categories 的 store 参数，只在该门店中查找商品
+}
+
+// importRequest 导入浏览器本地收藏的请求，product_ids 为页面 localStorage 中 productFavorites 的内容
+type importRequest struct {
+	ProductIDs []string `json:"product_ids"`
+	Store      string   `json:"store"` // 页面当前的门店，同 watchRequest
+}
+
+// readRequest 读取JSON请求体，JSON以外的请求按表单读取；请求体不超过 limit 字节，失败时已输出错误
+func readRequest(w http.ResponseWriter, r *http.Request, v interface{}, limit int64) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		err = json.NewDecoder(r.Body).Decode(v)
+	} else {
+		err = r.ParseForm()
+	}
+	if err == nil {
+		return true
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, "请求体不能超过%d字节", limit)
+	} else {
+		writeJSONError(w, http.StatusBadRequest, "解析请求失败: %v", err)
+	}
+	return false
+}
+
+// writeWatchlistError 输出修改收藏夹的错误，超出数量限制时为409
+func writeWatchlistError(w http.ResponseWriter, err error) {
+	var limit watchlistLimitError
+	if errors.As(err, &limit) {
+		writeJSONError(w, http.StatusConflict, "%v", err)
+		return
+	}
+	writeJSONError(w, http.StatusInternalServerError, "%v", err)
+}
+
+// checkWatchlistRequest 检查请求中的收藏夹ID，失败时已输出错误
+func checkWatchlistRequest(w http.ResponseWriter, r *http.Request, config *Config) bool {
+	if err := config.Watchlists.checkWatchlistID(r.PathValue("id")); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "%v", err)
+		return false
+	}
+	return true
+}
+
+// handleWatchlist 收藏夹中的商品及当前价格，所有门店中查找收藏的商品
+func handleWatchlist(w http.ResponseWriter, r *http.Request, config *Config) {
+	if !checkWatchlistRequest(w, r, config) {
+		return
+	}
+	watchlist, err := getWatchlist(config.Watchlists, r.PathValue("id"))
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+	stores, _ := config.selectStores(AllStores)
+	data := fetchStores(r.Context(), *config, stores)
+	w.Header().Set("Age", strconv.FormatInt(data.maxCacheAge(), 10))
+	writeJSON(w, http.StatusOK, watchlistView(*config, watchlist, data))
+}
+
+// handleWatchProduct 收藏商品，商品名称等信息从当前页面数据中读取
+func handleWatchProduct(w http.ResponseWriter, r *http.Request, config *Config) {
+	if !checkWatchlistRequest(w, r, config) {
+		return
+	}
+	var req watchRequest
+	if !readRequest(w, r, &req, maxWatchlistRequestSize) {
+		return
+	}
+	if r.Form != nil { // 表单请求
+		req.ProductID, req.Store = r.FormValue("product_id"), r.FormValue("store")
+	}
+	if req.ProductID == "" {
+		writeJSONError(w, http.StatusBadRequest, "缺少product_id")
+		return
+	}
+	updateWatchlistProducts(w, r, *config, req.Store, []string{req.ProductID})
+}
+
+// handleImportWatchlist 导入浏览器本地的收藏，已收藏的商品不会重复添加
+func handleImportWatchlist(w http.ResponseWriter, r *http.Request, config *Config) {
+	if !checkWatchlistRequest(w, r, config) {
+		return
+	}
+	var req importRequest
+	if !readRequest(w, r, &req, maxWatchlistRequestSize) {
+		return
+	}
+	if len(req.ProductIDs) > maxWatchlistProducts {
+		writeJSONError(w, http.StatusBadRequest, "每个收藏夹最多收藏%d个商品", maxWatchlistProducts)
+		return
+	}
+	updateWatchlistProducts(w, r, *config, req.Store, req.ProductIDs)
+}
+
+// updateWatchlistProducts 将商品加入请求中的收藏夹，store 为查找商品信息的门店，为空时为默认门店，
+// 返回新增数量和修改后的收藏夹
+func updateWatchlistProducts(w http.ResponseWriter, r *http.Request, config Config, store string, ids []string) {
+	stores, err := config.selectStores(store)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	products := watchedProducts(fetchStores(r.Context(), config, stores), ids)
+	added := 0
+	watchlist, err := updateWatchlist(config.Watchlists, r.PathValue("id"), func(list *Watchlist) (err error) {
+		added, err = list.add(products, time.Now())
+		return err
+	})
+	if err != nil {
+		writeWatchlistError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"added":     added,
+		"watchlist": watchlist,
+	})
+}
+
+// handleUnwatchProduct 取消收藏，商品不在收藏夹中时返回404
+func handleUnwatchProduct(w http.ResponseWriter, r *http.Request, config *Config) {
+	if !checkWatchlistRequest(w, r, config) {
+		return
+	}
+	product := r.PathValue("product")
+	removed := false
+	watchlist, err := updateWatchlist(config.Watchlists, r.PathValue("id"), func(list *Watchlist) error {
+		removed = list.remove(product)
+		return nil
+	})
+	if err != nil {
+		writeWatchlistError(w, err)
+		return
+	}
+	if !removed {
+		writeJSONError(w, http.StatusNotFound, "商品不在收藏夹中: %s", product)
+		return
+	}
+	writeJSON(w, http.StatusOK, watchlist)
+}
+
+// handleClearWatchlist 清空收藏夹
+func handleClearWatchlist(w http.ResponseWriter, r *http.Request, config *Config) {
+	if !checkWatchlistRequest(w, r, config) {
+		return
+	}
+	watchlist, err := updateWatchlist(config.Watchlists, r.PathValue("id"), func(list *Watchlist) error {
+		list.Products = make([]WatchedProduct, 0)
+		return nil
+	})
+	if err != nil {
+		writeWatchlistError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, watchlist)
+}
+
+// handleStores 配置的门店列表，第一个为默认门店
+func handleStores(w http.ResponseWriter, r *http.Request, config *Config) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"stores": publicStores(config.Stores),
+	})
+}
+
+// handleDebug 调试信息
+func handleDebug(w http.ResponseWriter, r *http.Request, config *Config) {
+	resp, err := HandleRequest(r.Context(), AliyunFunctionRequest{Mode: "debug", config: config})
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "执行失败: %v", err)
+		return
+	}
+	writeFunctionResponse(w, resp)
+}
+
+// handleHealth 健康检查
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestServeMuxRoutes 测试HTTP服务的健康检查和未知路由，接口使用启动时传入的配置
+func TestServeMuxRoutes(t *testing.T) {
+	server := httptest.NewServer(newServeMux(&Config{Stores: []StoreConfig{{ID: "012", Name: "文华路店"}}}))
+	defer server.Close()
+
+	testCases := []struct {
+		path   string
+		status int
+	}{
+		{"/healthz", http.StatusOK},
+		{"/not-found", http.StatusNotFound},
+		{"/static/css/tailwind.css", http.StatusOK},
+		{"/static/fonts/fontawesome-webfont.woff2", http.StatusOK},
+		{"/static/css/", http.StatusNotFound},
+		{"/static/missing.css", http.StatusNotFound},
+		{"/?format=xml", http.StatusBadRequest},
+		{"/api/stores", http.StatusOK},
+		{"/api/watchlists/ab", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		resp, err := http.Get(server.URL + tc.path)
+		if err != nil {
+			t.Fatalf("请求 %s 失败: %v", tc.path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Errorf("GET %s 状态码 = %d, 期望 %d", tc.path, resp.StatusCode, tc.status)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// ShoppingItem 购物清单中的一项，数量为重量或件数
+type ShoppingItem struct {
+	Text      string  `json:"text"`                 // 原始文本，如 西红柿 2斤
+	Name      string  `json:"name"`                 // 商品名称，如 西红柿
+	Key       string  `json:"key"`                  // 规范名称
+	Jin       float64 `json:"jin,omitempty"`        // 需要的重量（斤），按件数购买时为0
+	Count     float64 `json:"count,omitempty"`      // 需要的件数，按重量购买时为0
+	CountUnit string  `json:"count_unit,omitempty"` // 件数的单位，如 把、个，没写单位时为空
+}
+
+// Quantity 数量的显示文本
+func (item ShoppingItem) Quantity() string {
+	if item.Jin > 0 {
+		return formatAmount(item.Jin) + "斤"
+	}
+	return formatAmount(item.Count) + item.CountUnit
+}
+
+// ShoppingOption 清单中一项的一个可选商品及其花费
+type ShoppingOption struct {
+	ProductID   string  `json:"product_id"`
+	Name        string  `json:"name"`
+	Spec        string  `json:"spec"`
+	CategoryID  string  `json:"category_id"`
+	Store       string  `json:"store,omitempty"`
+	StoreName   string  `json:"store_name,omitempty"`
+	Price       float64 `json:"price"`         // 标价
+	PricePerJin float64 `json:"price_per_jin"` // 每斤价格，包装商品按规格中的重量折算，无法折算时为0
+	Unit        string  `json:"unit"`          // 商品的价格单位
+	Cost        float64 `json:"cost"`          // 按清单数量的花费（元）
+	Basis       string  `json:"basis"`         // 计算方式，如 2斤 × 3.98元/斤
+}
+
+// ShoppingLine 匹配到商品的一项
+type ShoppingLine struct {
+	ShoppingItem
+	Cost         float64          `json:"cost"`         // 选用商品的花费，即第一个可选商品
+	Choice       ShoppingOption   `json:"choice"`       // 选用的商品
+	Alternatives []ShoppingOption `json:"alternatives"` // 所有匹配的商品，按每斤价格从低到高，无法折算每斤价格的按花费排在后面
+}
+
+// ShoppingResult 购物清单的计算结果
+type ShoppingResult struct {
+	Lines     []ShoppingLine `json:"lines"`     // 匹配到商品的项
+	Unmatched []ShoppingItem `json:"unmatched"` // 没有匹配到商品的项
+	Total     float64        `json:"total"`     // 匹配项的合计（元）
+}
+
+// 购物清单的数量限制，避免一个请求解析和匹配过多的项
+const (
+	maxShoppingItems       = 100      // 购物清单最多的项数
+	maxShoppingRequestSize = 16 << 10 // 购物清单请求体的最大字节数
+)
+
+// shoppingSeparator 清单中各项的分隔符
+var shoppingSeparator = regexp.MustCompile(`[,，;；、\n]+`)
+
+// shoppingAmountSuffix 名称在前、数量在后，如 西红柿 2斤、香菜1把
+var shoppingAmountSuffix = regexp.MustCompile(`^(.+?)\s*((?:\d+(?:\.\d+)?\s*|[零一二两三四五六七八九十百半]+)\S*)$`)
+
+// shoppingAmountPrefix 数量在前、名称在后，如 2斤西红柿、一把香菜
+var shoppingAmountPrefix = regexp.MustCompile(`^((?:\d+(?:\.\d+)?|[零一二两三四五六七八九十百半]+)\s*(?:公斤|千克|kg|斤|两|克|g|` + strings.Join(packUnits, "|") + `))\s*(.+)$`)
+
+// parseShoppingList 解析购物清单，各项用逗号、顿号、分号或换行分隔，没写数量时为1件
+func parseShoppingList(config Config, text string) []ShoppingItem {
+	catalog := catalogFor(config)
+	var items []ShoppingItem
+	for _, part := range shoppingSeparator.Split(text, -1) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		item := parseShoppingItem(part)
+		item.Key = catalog.Key(item.Name)
+		items = append(items, item)
+	}
+	return items
+}
+
+// parseShoppingItem 解析清单中的一项，数量无法识别时整项作为名称
+func parseShoppingItem(text string) ShoppingItem {
+	item := ShoppingItem{Text: text, Name: text, Count: 1}
+	name, amount := "", ""
+	if m := shoppingAmountSuffix.FindStringSubmatch(text); m != nil {
+		name, amount = m[1], m[2]
+	} else if m := shoppingAmountPrefix.FindStringSubmatch(text); m != nil {
+		name, amount = m[2], m[1]
+	}
+	if amount == "" {
+		return item
+	}
+
+	info := parseSpec(amount)
+	switch {
+	case info.HasWeight() && !info.Estimated:
+		item.Jin, item.Count = info.Weight(), 0
+	case info.PackUnit != "" && info.Quantity > 0:
+		item.Count, item.CountUnit = info.Quantity*info.PackCount, info.PackUnit
+	default:
+		// 只有数字没有单位时按件数，其余无法识别的保留为名称的一部分
+		n, err := strconv.ParseFloat(strings.TrimSpace(amount), 64)
+		if err != nil || n <= 0 {
+			return item
+		}
+		item.Count = n
+	}
+	item.Name = strings.TrimSpace(name)
+	return item
+}
+
+// shoppingOption 按清单数量计算商品的花费，无法计算时返回false。
+// 按重量卖的商品：重量 × 每斤价格；按件数购买时按商品规格或估算重量折算；
+// 包装商品：按规格中的重量计算需要几件，没有重量时按件数
+func shoppingOption(config Config, item ShoppingItem, c Category, p Product) (ShoppingOption, bool) {
+	if p.Price <= 0 {
+		return ShoppingOption{}, false
+	}
+	option := ShoppingOption{
+		ProductID:  p.ID,
+		Name:       p.Name,
+		Spec:       p.Spec,
+		CategoryID: c.ID,
+		Store:      p.Store,
+		StoreName:  p.StoreName,
+		Price:      p.Price,
+		Unit:       p.Unit,
+	}
+	info := parseSpec(p.Spec)
+	count := item.Count
+	if count <= 0 {
+		count = 1
+	}
+
+	if !p.IsPackaged && p.PricePerJin > 0 {
+		option.PricePerJin = p.PricePerJin
+		switch {
+		case item.Jin > 0:
+			option.Cost = item.Jin * p.PricePerJin
+			option.Basis = fmt.Sprintf("%s斤 × %.2f元/斤", formatAmount(item.Jin), p.PricePerJin)
+		case item.CountUnit != "" && item.CountUnit == info.PackUnit:
+			// 商品本身按件卖，如 西瓜 1个
+			option.Cost = count * p.Price
+			option.Basis = fmt.Sprintf("%s%s × %.2f元", formatAmount(count), item.CountUnit, p.Price)
+		default:
+			if jin, _, ok := findWeightEstimate(config, p.Name, item.CountUnit); ok && item.CountUnit != "" {
+				option.Cost = count * jin * p.PricePerJin
+				option.Basis = fmt.Sprintf("%s%s × 约%s斤 × %.2f元/斤", formatAmount(count), item.CountUnit, formatAmount(jin), p.PricePerJin)
+			} else {
+				option.Cost = count * p.Price
+				option.Basis = fmt.Sprintf("%s份 × %.2f元（%s）", formatAmount(count), p.Price, p.Spec)
+			}
+		}
+	} else {
+		packUnit := strings.TrimPrefix(p.Unit, "元/")
+		if info.HasWeight() && !info.Estimated {
+			option.PricePerJin = p.Price / info.Weight()
+		}
+		if item.Jin > 0 && option.PricePerJin > 0 {
+			packs := math.Ceil(item.Jin/info.Weight() - 1e-9)
+			option.Cost = packs * p.Price
+			option.Basis = fmt.Sprintf("%s%s × %.2f元（每%s%s斤）", formatAmount(packs), packUnit, p.Price, packUnit, formatAmount(info.Weight()))
+		} else {
+			option.Cost = count * p.Price
+			option.Basis = fmt.Sprintf("%s%s × %.2f元", formatAmount(count), packUnit, p.Price)
+		}
+	}
+	option.Cost = math.Round(option.Cost*100) / 100
+	return option, true
+}
+
+// calculateShoppingList 在页面数据中查找清单中的商品并计算花费。
+// 商品的规范名称与清单项相同，或名称中包含清单项名称时视为匹配；抓取失败的分类不参与
+func calculateShoppingList(config Config, data PageData, items []ShoppingItem) ShoppingResult {
+	result := ShoppingResult{Lines: make([]ShoppingLine, 0), Unmatched: make([]ShoppingItem, 0)}
+	for _, item := range items {
+		var options []ShoppingOption
+		for _, c := range data.Categories {
+			if c.Status == CategoryStatusFailed {
+				continue
+			}
+			for _, p := range c.Products {
+				if (item.Key == "" || p.CanonicalKey != item.Key) && !strings.Contains(p.Name, item.Name) {
+					continue
+				}
+				if option, ok := shoppingOption(config, item, c, p); ok {
+					options = append(options, option)
+				}
+			}
+		}
+		if len(options) == 0 {
+			result.Unmatched = append(result.Unmatched, item)
+			continue
+		}
+
+		sort.SliceStable(options, func(i, j int) bool {
+			a, b := options[i], options[j]
+			if (a.PricePerJin > 0) != (b.PricePerJin > 0) {
+				return a.PricePerJin > 0
+			}
+			if a.PricePerJin != b.PricePerJin {
+				return a.PricePerJin < b.PricePerJin
+			}
+			return a.Cost < b.Cost
+		})
+		result.Lines = append(result.Lines, ShoppingLine{
+			ShoppingItem: item,
+			Cost:         options[0].Cost,
+			Choice:       options[0],
+			Alternatives: options,
+		})
+		result.Total += options[0].Cost
+	}
+	result.Total = math.Round(result.Total*100) / 100
+	return result
+}
+
+// formatAmount 数量的显示文本，去掉多余的0
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// runShoppingMode 计算购物清单的花费，清单从参数读取，没有参数时从标准输入读取
+// 用法: shopping [-store 门店ID] [-json] "西红柿 2斤, 土豆 3斤, 香菜 1把"
+func runShoppingMode(args []string) {
+	flags := flag.NewFlagSet("shopping", flag.ExitOnError)
+	storeFlag := flags.String("store", "", "门店ID，多个用逗号分隔，all 为所有门店，默认第一个门店")
+	asJSON := flags.Bool("json", false, "输出JSON")
+	flags.Parse(args)
+
+	text := strings.Join(flags.Args(), "\n")
+	if text == "" {
+		var lines []string
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		text = strings.Join(lines, "\n")
+	}
+
+	config, err := LoadConfig("config.json")
+	if err != nil {
+		fmt.Printf("加载配置文件失败: %v\n", err)
+		return
+	}
+	items := parseShoppingList(*config, text)
+	if len(items) == 0 {
+		fmt.Println(`用法: vegetable-price shopping [-store 门店ID] [-json] "西红柿 2斤, 土豆 3斤, 香菜 1把"`)
+		return
+	}
+	stores, err := config.selectStores(*storeFlag)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	data := fetchStoresConcurrently(context.Background(), *config, stores)
+	result := calculateShoppingList(*config, data, items)
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(result)
+		return
+	}
+	printShoppingResult(result)
+}
+
+// printShoppingResult 以表格形式打印购物清单，每项列出选用的商品和其他可选商品
+func printShoppingResult(result ShoppingResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, line := range result.Lines {
+		fmt.Fprintf(w, "%s %s\t%s%s\t%s\t%.2f元\n", line.Name, line.Quantity(), line.Choice.Name, storeSuffix(line.Choice.StoreName), line.Choice.Basis, line.Cost)
+		for _, alt := range line.Alternatives[1:] {
+			fmt.Fprintf(w, "\t  或 %s%s\t%s\t%.2f元\n", alt.Name, storeSuffix(alt.StoreName), alt.Basis, alt.Cost)
+		}
+	}
+	w.Flush()
+	for _, item := range result.Unmatched {
+		fmt.Printf("未找到: %s\n", item.Text)
+	}
+	fmt.Printf("合计: %.2f元\n", result.Total)
+}
+
+// storeSuffix 门店名称后缀，没有门店时为空
+func storeSuffix(name string) string {
+	if name == "" {
+		return ""
+	}
+	return "（" + name + "）"
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestShoppingList 测试购物清单的解析、匹配商品和花费计算
+func TestShoppingList(t *testing.T) {
+	parseCases := []struct {
+		text  string
+		name  string
+		jin   float64
+		count float64
+		unit  string
+	}{
+		{"西红柿 2斤", "西红柿", 2, 0, ""},
+		{"2斤西红柿", "西红柿", 2, 0, ""},
+		{"香菜1把", "香菜", 0, 1, "把"},
+		{"一把香菜", "香菜", 0, 1, "把"},
+		{"土豆 500g", "土豆", 1, 0, ""},
+		{"三七 2两", "三七", 0.2, 0, ""},
+		{"鸡蛋 3", "鸡蛋", 0, 3, ""},
+		{"黄瓜", "黄瓜", 0, 1, ""},
+	}
+	for _, tc := range parseCases {
+		item := parseShoppingItem(tc.text)
+		if item.Name != tc.name || math.Abs(item.Jin-tc.jin) > 1e-9 || item.Count != tc.count || item.CountUnit != tc.unit {
+			t.Errorf("parseShoppingItem(%q) = %+v", tc.text, item)
+		}
+	}
+
+	config := Config{}
+	data := PageData{Categories: []Category{
+		{ID: "fruit", Status: CategoryStatusOK, Products: []Product{
+			{ID: "1", Name: "西红柿", CanonicalKey: "西红柿", Price: 3.98, Spec: "500g", PricePerJin: 3.98, Unit: "元/斤"},
+			{ID: "2", Name: "【精品】番茄", CanonicalKey: "西红柿", Price: 6.98, Spec: "2斤", PricePerJin: 6.98, IsPackaged: true, Unit: "元/盒"},
+			{ID: "3", Name: "茄子", CanonicalKey: "茄子", Price: 2.00, Spec: "500g", PricePerJin: 2.00, Unit: "元/斤"},
+		}},
+		{ID: "root", Status: CategoryStatusOK, Products: []Product{
+			{ID: "4", Name: "马铃薯", CanonicalKey: "土豆", Price: 1.50, Spec: "1斤", PricePerJin: 1.50, Unit: "元/斤"},
+		}},
+		{ID: "condiment", Status: CategoryStatusOK, Products: []Product{
+			{ID: "5", Name: "香菜", CanonicalKey: "香菜", Price: 2.00, Spec: "1把", PricePerJin: 2.00, IsPackaged: true, Unit: "元/把"},
+		}},
+	}}
+	items := parseShoppingList(config, "西红柿 2斤, 土豆 3斤、香菜 1把\n茄子 3个；榴莲 1个")
+	if len(items) != 5 || items[1].Key != "土豆" {
+		t.Fatalf("parseShoppingList = %+v", items)
+	}
+	result := calculateShoppingList(config, data, items)
+
+	var got []string
+	for _, line := range result.Lines {
+		got = append(got, fmt.Sprintf("%s:%s:%.2f:%d", line.Name, line.Choice.ProductID, line.Cost, len(line.Alternatives)))
+	}
+	// 精品番茄按盒折算每斤3.49元，比散装西红柿便宜，2斤需要1盒；茄子按每个约0.6斤估算
+	want := "西红柿:2:6.98:2,土豆:4:4.50:1,香菜:5:2.00:1,茄子:3:3.60:1"
+	if strings.Join(got, ",") != want {
+		t.Errorf("清单结果 = %v, 期望 %s", got, want)
+	}
+	if alt := result.Lines[0].Alternatives[1]; alt.ProductID != "1" || alt.Cost != 7.96 || alt.Basis != "2斤 × 3.98元/斤" {
+		t.Errorf("西红柿的其他选择 = %+v", alt)
+	}
+	if len(result.Unmatched) != 1 || result.Unmatched[0].Name != "榴莲" {
+		t.Errorf("未匹配 = %+v", result.Unmatched)
+	}
+	if result.Total != 17.08 {
+		t.Errorf("合计 = %.2f, 期望 17.08", result.Total)
+	}
+
+	data.PriceUnit = defaultPriceUnit
+	data.Shopping = &result
+	data.List = "西红柿 2斤"
+	out, err := renderProductList("", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`data-category-index="shopping" data-active`, ">西红柿 2斤</textarea>", "或 西红柿", "<td>榴莲 1个</td>", "17.08元"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("页面中缺少 %q", want)
+		}
+	}
+}
+
+// TestShoppingLimits 测试购物清单的项数和请求体大小限制，超出时不抓取直接返回错误
+func TestShoppingLimits(t *testing.T) {
+	config := &Config{Stores: []StoreConfig{{ID: "012", Name: "文华路店"}}}
+	mux := newServeMux(config)
+	tooMany := strings.Repeat("西红柿 1斤,", maxShoppingItems+1)
+
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"JSON项数过多", "application/json", fmt.Sprintf(`{"list": %q}`, tooMany), http.StatusBadRequest},
+		{"表单项数过多", "application/x-www-form-urlencoded", "list=" + url.QueryEscape(tooMany), http.StatusBadRequest},
+		{"JSON请求体过大", "application/json", `{"list": "` + strings.Repeat("a", maxShoppingRequestSize) + `"}`, http.StatusRequestEntityTooLarge},
+		{"表单请求体过大", "application/x-www-form-urlencoded", "list=" + strings.Repeat("a", maxShoppingRequestSize), http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodPost, "/api/shopping", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", tc.contentType)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Errorf("%s: 状态码 = %d, 期望 %d, %s", tc.name, rec.Code, tc.status, rec.Body.String())
+		}
+	}
+
+	// 页面和阿里云函数的 list 参数同样限制项数
+	resp, err := HandleHttpRequestWithHtml(t.Context(), AliyunFunctionRequest{Format: FormatJSON, List: tooMany, config: config})
+	if err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("list 参数项数过多 = %d, %v, 期望 400", resp.StatusCode, err)
+	}
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SpecInfo 规格解析结果，重量统一为斤
+type SpecInfo struct {
+	Quantity   float64 // 单件数量，如 500g*2袋 中的 500，范围时为下限
+	Unit       string  // 单件数量的单位，如 g、斤、个
+	PackCount  float64 // 件数，如 500g*2袋 中的 2，没有时为1
+	PackUnit   string  // 包装单位，如 袋、盒，没有时为空
+	MinWeight  float64 // 总重量下限（斤）
+	MaxWeight  float64 // 总重量上限（斤），没有范围时与下限相同
+	Estimated  bool    // 重量是按件数估算的
+	Confidence float64 // 可信度：0 无法解析，1 明确标注的重量，约数、范围、估算依次降低
+}
+
+// Weight 总重量（斤），范围取中值
+func (s SpecInfo) Weight() float64 {
+	return (s.MinWeight + s.MaxWeight) / 2
+}
+
+// HasWeight 是否解析到了重量
+func (s SpecInfo) HasWeight() bool {
+	return s.MaxWeight > 0
+}
+
+// 可信度
+const (
+	confidenceExact     = 1.0 // 明确的重量，如 500g
+	confidenceVolume    = 0.9 // 按体积换算，如 500ml
+	confidenceApprox    = 0.8 // 约数，如 约500g、500g左右
+	confidenceRange     = 0.7 // 范围，如 2-3斤
+	confidenceEstimated = 0.3 // 按件数估算，如 3个
+)
+
+// weightUnitGrams 重量单位对应的克数，体积单位按水的密度换算
+var weightUnitGrams = map[string]float64{
+	"斤": 500, "公斤": 1000, "千克": 1000, "kg": 1000,
+	"克": 1, "g": 1, "两": 50, "磅": 453.5, "lb": 453.5, "lbs": 453.5,
+	"毫升": 1, "ml": 1, "升": 1000, "l": 1000,
+}
+
+// volumeUnits 体积单位
+var volumeUnits = map[string]bool{"毫升": true, "ml": true, "升": true, "l": true}
+
+// packUnits 包装和计件单位
+var packUnits = []string{"袋", "盒", "包", "筐", "箱", "个", "只", "束", "把", "根", "瓶", "罐", "颗", "棵", "头", "份", "件", "提", "板", "枚", "条", "扎", "节"}
+
+// pieceWeights 每件的估算重量（斤）
+var pieceWeights = map[string]float64{
+	"盒": 0.5, "袋": 1.0, "包": 1.0, "个": 0.2, "只": 0.3, "束": 0.5, "把": 0.3, "根": 0.1,
+}
+
+// specUnitWords 按长度从长到短匹配的中文单位，“两”单独处理
+var specUnitWords = []string{"公斤", "千克", "毫升", "斤", "克", "磅", "升"}
+
+// 规格中的记号
+const (
+	specNumber = iota // 数字
+	specWeight        // 重量单位
+	specPack          // 包装单位
+	specTimes         // 乘号：* × x
+	specRange         // 范围：- ~ 至 到
+	specPer           // 每：/ 每
+	specHalf          // 单位后的“半”，如 斤半
+)
+
+type specToken struct {
+	kind  int
+	value float64
+	unit  string
+}
+
+// parseSpec 解析规格，支持：
+//   - 重量：500g、1.5kg、2斤、10两、半斤、一斤二两、1斤半
+//   - 约数：约500g、500g左右、≈500g
+//   - 范围：2-3斤、2斤~3斤、250至300g
+//   - 多件：500g*2袋、250g×4、2袋*500g、1.5kg/袋
+//   - 计件：3个、1盒，按每件的估算重量计算
+func parseSpec(spec string) SpecInfo {
+	info := SpecInfo{PackCount: 1}
+	tokens, approx := tokenizeSpec(spec)
+
+	for i := range tokens {
+		low, high, unit, quantity, next, ok := parseWeightTerm(tokens, i)
+		if !ok {
+			continue
+		}
+		info.Quantity = quantity
+		info.Unit = unit
+		info.Confidence = confidenceExact
+		if volumeUnits[unit] {
+			info.Confidence = confidenceVolume
+		}
+		if high > low {
+			info.Confidence = min(info.Confidence, confidenceRange)
+		}
+
+		// 件数：500g*2袋、500g/袋、2袋*500g、2×250g
+		count, packUnit := 1.0, ""
+		switch {
+		case next+1 < len(tokens) && tokens[next].kind == specTimes && tokens[next+1].kind == specNumber:
+			count = tokens[next+1].value
+			if next+2 < len(tokens) && tokens[next+2].kind == specPack {
+				packUnit = tokens[next+2].unit
+			}
+		case next+1 < len(tokens) && tokens[next].kind == specPer && tokens[next+1].kind == specPack:
+			packUnit = tokens[next+1].unit
+		case i >= 2 && tokens[i-1].kind == specTimes && tokens[i-2].kind == specNumber:
+			count = tokens[i-2].value
+		case i >= 3 && tokens[i-1].kind == specTimes && tokens[i-2].kind == specPack && tokens[i-3].kind == specNumber:
+			count, packUnit = tokens[i-3].value, tokens[i-2].unit
+		case next < len(tokens) && tokens[next].kind == specPack:
+			packUnit = tokens[next].unit
+		}
+		if count <= 0 {
+			count = 1
+		}
+		info.PackCount = count
+		info.PackUnit = packUnit
+		info.MinWeight = low * count / 500
+		info.MaxWeight = high * count / 500
+		break
+	}
+
+	if !info.HasWeight() {
+		// 没有重量时按件数估算
+		for i, tok := range tokens {
+			if tok.kind != specPack {
+				continue
+			}
+			count := 1.0
+			if i > 0 && tokens[i-1].kind == specNumber {
+				count = tokens[i-1].value
+			}
+			info.Quantity = count
+			info.Unit = tok.unit
+			info.PackUnit = tok.unit
+			if weight, ok := pieceWeights[tok.unit]; ok && count > 0 {
+				info.MinWeight = count * weight
+				info.MaxWeight = info.MinWeight
+				info.Estimated = true
+				info.Confidence = confidenceEstimated
+			}
+			break
+		}
+	}
+
+	// 数字过大时当作无法解析
+	if math.IsInf(info.MaxWeight, 0) {
+		return SpecInfo{PackCount: 1}
+	}
+	if approx && info.Confidence > 0 {
+		info.Confidence *= confidenceApprox
+	}
+	return info
+}
+
+// parseWeightTerm 从第 i 个记号开始解析重量，返回克数范围、单位、单件数量和之后的位置。
+// 支持 500g、2-3斤、2斤-3斤、一斤二两、1斤半
+func parseWeightTerm(tokens []specToken, i int) (minGrams, maxGrams float64, unit string, quantity float64, next int, ok bool) {
+	if i >= len(tokens) || tokens[i].kind != specNumber {
+		return 0, 0, "", 0, i, false
+	}
+	low := tokens[i].value
+	high := low
+	j := i + 1
+
+	// 2-3斤
+	if j+1 < len(tokens) && tokens[j].kind == specRange && tokens[j+1].kind == specNumber {
+		high = tokens[j+1].value
+		j += 2
+	}
+	if j >= len(tokens) || tokens[j].kind != specWeight {
+		return 0, 0, "", 0, i, false
+	}
+	unit = tokens[j].unit
+	grams := weightUnitGrams[unit]
+	minGrams, maxGrams = low*grams, high*grams
+	quantity = low
+	j++
+
+	// 2斤-3斤
+	if high == low && j+2 < len(tokens) && tokens[j].kind == specRange && tokens[j+1].kind == specNumber && tokens[j+2].kind == specWeight {
+		maxGrams = tokens[j+1].value * weightUnitGrams[tokens[j+2].unit]
+		j += 3
+	}
+
+	// 一斤二两、1斤半：后面跟着更小的单位时累加
+	for j < len(tokens) {
+		switch {
+		case tokens[j].kind == specHalf:
+			minGrams += grams / 2
+			maxGrams += grams / 2
+			j++
+			continue
+		case j+1 < len(tokens) && tokens[j].kind == specNumber && tokens[j+1].kind == specWeight && weightUnitGrams[tokens[j+1].unit] < grams:
+			extra := tokens[j].value * weightUnitGrams[tokens[j+1].unit]
+			minGrams += extra
+			maxGrams += extra
+			grams = weightUnitGrams[tokens[j+1].unit]
+			j += 2
+			continue
+		}
+		break
+	}
+
+	if maxGrams < minGrams {
+		minGrams, maxGrams = maxGrams, minGrams
+	}
+	if maxGrams <= 0 {
+		return 0, 0, "", 0, i, false
+	}
+	return minGrams, maxGrams, unit, quantity, j, true
+}
+
+// tokenizeSpec 将规格拆分为记号，同时返回是否为约数
+func tokenizeSpec(spec string) ([]specToken, bool) {
+	runes := []rune(normalizeSpec(spec))
+	var tokens []specToken
+	approx := false
+
+	last := func() int {
+		if len(tokens) == 0 {
+			return -1
+		}
+		return tokens[len(tokens)-1].kind
+	}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r >= '0' && r <= '9' || r == '.' && i+1 < len(runes) && runes[i+1] >= '0' && runes[i+1] <= '9':
+			start := i
+			for i < len(runes) && (runes[i] >= '0' && runes[i] <= '9' || runes[i] == '.') {
+				i++
+			}
+			value, err := strconv.ParseFloat(strings.TrimRight(string(runes[start:i]), "."), 64)
+			if err == nil {
+				tokens = append(tokens, specToken{kind: specNumber, value: value})
+			}
+			continue
+
+		case r == '半':
+			// 斤半 是在前面的单位上加半个，半斤 是0.5
+			if last() == specWeight {
+				tokens = append(tokens, specToken{kind: specHalf})
+			} else {
+				tokens = append(tokens, specToken{kind: specNumber, value: 0.5})
+			}
+			i++
+			continue
+
+		case r == '两':
+			// 2两、一斤二两 中是单位，两斤 中是数字
+			if last() == specNumber {
+				tokens = append(tokens, specToken{kind: specWeight, unit: "两"})
+			} else {
+				tokens = append(tokens, specToken{kind: specNumber, value: 2})
+			}
+			i++
+			continue
+
+		case matchSpecUnit(runes[i:]) != "":
+			// 先于中文数字匹配，千克 中的千是单位
+			unit := matchSpecUnit(runes[i:])
+			tokens = append(tokens, specToken{kind: specWeight, unit: unit})
+			i += len([]rune(unit))
+			continue
+
+		case isChineseDigit(r):
+			start := i
+			for i < len(runes) && isChineseDigit(runes[i]) {
+				i++
+			}
+			if value, ok := parseChineseNumber(runes[start:i]); ok {
+				tokens = append(tokens, specToken{kind: specNumber, value: value})
+			}
+			continue
+
+		case r >= 'a' && r <= 'z':
+			start := i
+			for i < len(runes) && runes[i] >= 'a' && runes[i] <= 'z' {
+				i++
+			}
+			word := string(runes[start:i])
+			// 250gx4 中单位和乘号连在一起
+			times := word != "x" && strings.HasSuffix(word, "x") && weightUnitGrams[strings.TrimSuffix(word, "x")] > 0
+			if times {
+				word = strings.TrimSuffix(word, "x")
+			}
+			switch {
+			case word == "x":
+				tokens = append(tokens, specToken{kind: specTimes})
+			case weightUnitGrams[word] > 0:
+				tokens = append(tokens, specToken{kind: specWeight, unit: word})
+			}
+			if times {
+				tokens = append(tokens, specToken{kind: specTimes})
+			}
+			continue
+
+		case r == '*' || r == '×':
+			tokens = append(tokens, specToken{kind: specTimes})
+		case r == '-' || r == '~' || r == '至' || r == '到':
+			if r == '~' && last() != specNumber && last() != specWeight {
+				// 开头的 ~ 表示约数
+				approx = true
+			} else {
+				tokens = append(tokens, specToken{kind: specRange})
+			}
+		case r == '/' || r == '每':
+			tokens = append(tokens, specToken{kind: specPer})
+		case r == '约' || r == '≈':
+			approx = true
+		case r == '左' && i+1 < len(runes) && runes[i+1] == '右':
+			approx = true
+			i += 2
+			continue
+		default:
+			if containsString(packUnits, string(r)) {
+				tokens = append(tokens, specToken{kind: specPack, unit: string(r)})
+			}
+		}
+		i++
+	}
+	return tokens, approx
+}
+
+// matchSpecUnit 匹配开头的中文重量单位
+func matchSpecUnit(runes []rune) string {
+	// 单位最长两个字
+	head := string(runes[:min(len(runes), 2)])
+	for _, unit := range specUnitWords {
+		if strings.HasPrefix(head, unit) {
+			return unit
+		}
+	}
+	return ""
+}
+
+// normalizeSpec 全角字符转半角、大写转小写，统一乘号和范围符号
+func normalizeSpec(spec string) string {
+	var b strings.Builder
+	for _, r := range spec {
+		switch {
+		case r >= '！' && r <= '～':
+			r -= 0xFEE0
+		case r == '　':
+			r = ' '
+		case r == '—' || r == '–' || r == '〜':
+			r = '-'
+		case r == '╳' || r == '✕' || r == '✖':
+			r = '×'
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// isChineseDigit 是否为中文数字，“两”和“半”单独处理
+func isChineseDigit(r rune) bool {
+	return strings.ContainsRune("零一二三四五六七八九十百千", r)
+}
+
+// parseChineseNumber 解析中文数字，如 三、十二、二十五、一百零五
+func parseChineseNumber(runes []rune) (float64, bool) {
+	digits := map[rune]float64{'零': 0, '一': 1, '二': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+	scales := map[rune]float64{'十': 10, '百': 100, '千': 1000}
+
+	var total, digit float64
+	for _, r := range runes {
+		if d, ok := digits[r]; ok {
+			digit = d
+			continue
+		}
+		scale := scales[r]
+		if digit == 0 && r == '十' {
+			// 十二 中省略了一
+			digit = 1
+		}
+		total += digit * scale
+		digit = 0
+	}
+	total += digit
+	return total, total > 0
+}
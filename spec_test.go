@@ -0,0 +1,126 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// TestParseWeightFromSpec 测试重量解析功能
+func TestParseWeightFromSpec(t *testing.T) {
+	testCases := []struct {
+		spec     string
+		expected float64
+	}{
+		{"500g", 1.0}, // 500克 = 1斤
+		{"1kg", 2.0},  // 1公斤 = 2斤
+		{"2斤", 2.0},   // 2斤 = 2斤
+		{"500克", 1.0}, // 500克 = 1斤
+		{"1千克", 2.0},  // 1千克 = 2斤
+		{"10两", 1.0},  // 10两 = 1斤
+		{"1磅", 0.907}, // 1磅 ≈ 0.907斤
+		{"", 0},       // 空字符串
+		{"无规格", 0},    // 无数字
+	}
+
+	for _, tc := range testCases {
+		result := parseWeightFromSpec(tc.spec)
+		if result != tc.expected {
+			t.Errorf("parseWeightFromSpec(%q) = %f, 期望 %f", tc.spec, result, tc.expected)
+		}
+	}
+}
+
+// TestParseSpec 测试规格解析：约数、范围、多件、中文数字和计件估算
+func TestParseSpec(t *testing.T) {
+	testCases := []struct {
+		spec       string
+		weight     float64 // 总重量（斤）
+		min, max   float64
+		packCount  float64
+		packUnit   string
+		estimated  bool
+		confidence float64
+	}{
+		{"500g", 1, 1, 1, 1, "", false, 1},
+		{"约500g", 1, 1, 1, 1, "", false, 0.8},
+		{"500g左右", 1, 1, 1, 1, "", false, 0.8},
+		{"～500g", 1, 1, 1, 1, "", false, 0.8},
+		{"500g*2袋", 2, 2, 2, 2, "袋", false, 1},
+		{"500G×2袋", 2, 2, 2, 2, "袋", false, 1},
+		{"2袋*500g", 2, 2, 2, 2, "袋", false, 1},
+		{"250g×4", 2, 2, 2, 4, "", false, 1},
+		{"250gx4", 2, 2, 2, 4, "", false, 1},
+		{"4×250g", 2, 2, 2, 4, "", false, 1},
+		{"1.5kg/袋", 3, 3, 3, 1, "袋", false, 1},
+		{"1.5KG/袋", 3, 3, 3, 1, "袋", false, 1},
+		{"500g/盒", 1, 1, 1, 1, "盒", false, 1},
+		{"2-3斤", 2.5, 2, 3, 1, "", false, 0.7},
+		{"2斤-3斤", 2.5, 2, 3, 1, "", false, 0.7},
+		{"2~3斤", 2.5, 2, 3, 1, "", false, 0.7},
+		{"250至300g", 0.55, 0.5, 0.6, 1, "", false, 0.7},
+		{"约2-3斤", 2.5, 2, 3, 1, "", false, 0.7 * 0.8},
+		{"半斤", 0.5, 0.5, 0.5, 1, "", false, 1},
+		{"一斤", 1, 1, 1, 1, "", false, 1},
+		{"两斤", 2, 2, 2, 1, "", false, 1},
+		{"一斤二两", 1.2, 1.2, 1.2, 1, "", false, 1},
+		{"1斤半", 1.5, 1.5, 1.5, 1, "", false, 1},
+		{"一斤半", 1.5, 1.5, 1.5, 1, "", false, 1},
+		{"二两", 0.2, 0.2, 0.2, 1, "", false, 1},
+		{"十二两", 1.2, 1.2, 1.2, 1, "", false, 1},
+		{"1千克", 2, 2, 2, 1, "", false, 1},
+		{"2公斤装", 4, 4, 4, 1, "", false, 1},
+		{"１．５ｋｇ", 3, 3, 3, 1, "", false, 1},
+		{"500ml", 1, 1, 1, 1, "", false, 0.9},
+		{"1磅", 0.907, 0.907, 0.907, 1, "", false, 1},
+		{"3个", 0.6, 0.6, 0.6, 1, "个", true, 0.3},
+		{"1盒", 0.5, 0.5, 0.5, 1, "盒", true, 0.3},
+		{"袋装", 1, 1, 1, 1, "袋", true, 0.3},
+		{"1瓶", 0, 0, 0, 1, "瓶", false, 0},
+		{"", 0, 0, 0, 1, "", false, 0},
+		{"新鲜", 0, 0, 0, 1, "", false, 0},
+		{"约", 0, 0, 0, 1, "", false, 0},
+	}
+
+	near := func(a, b float64) bool { return a-b < 1e-9 && b-a < 1e-9 }
+	for _, tc := range testCases {
+		got := parseSpec(tc.spec)
+		if !near(got.Weight(), tc.weight) || !near(got.MinWeight, tc.min) || !near(got.MaxWeight, tc.max) ||
+			got.PackCount != tc.packCount || got.PackUnit != tc.packUnit || got.Estimated != tc.estimated || !near(got.Confidence, tc.confidence) {
+			t.Errorf("parseSpec(%q) = %+v, 期望 重量%v（%v~%v）件数%v%s 估算%v 可信度%v",
+				tc.spec, got, tc.weight, tc.min, tc.max, tc.packCount, tc.packUnit, tc.estimated, tc.confidence)
+		}
+	}
+
+	// 标明重量的包装按重量计算每斤价格
+	for spec, packaged := range map[string]bool{"500g*2袋": false, "1.5kg/袋": false, "1盒": true, "3个": true, "": true} {
+		if got := isPackagedProduct(spec); got != packaged {
+			t.Errorf("isPackagedProduct(%q) = %v, 期望 %v", spec, got, packaged)
+		}
+	}
+	if got := calculatePricePerJin(9.9, "500g*2袋"); !near(got, 4.95) {
+		t.Errorf("calculatePricePerJin(9.9, 500g*2袋) = %v, 期望 4.95", got)
+	}
+}
+
+// FuzzParseSpec 任意输入都不应panic，且结果满足基本约束
+func FuzzParseSpec(f *testing.F) {
+	for _, seed := range []string{"500g", "约500g", "500g*2袋", "2-3斤", "1.5kg/袋", "250g×4", "半斤", "一斤二两", "1斤半",
+		"两两两", "半半", "x*x", "-1斤", "3-2斤", "0g", "999999999999999999999999kg*99999999999999999999", "一百零五克", "十", "斤半", ".5kg", "5.kg"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, spec string) {
+		info := parseSpec(spec)
+		if math.IsNaN(info.MinWeight) || math.IsInf(info.MaxWeight, 0) || info.MinWeight < 0 || info.MinWeight > info.MaxWeight {
+			t.Fatalf("parseSpec(%q) 重量无效: %+v", spec, info)
+		}
+		if info.Confidence < 0 || info.Confidence > 1 || (info.Confidence > 0) != info.HasWeight() {
+			t.Fatalf("parseSpec(%q) 可信度无效: %+v", spec, info)
+		}
+		if info.Estimated && info.Confidence > confidenceEstimated {
+			t.Fatalf("parseSpec(%q) 估算重量的可信度过高: %+v", spec, info)
+		}
+		if info.PackCount <= 0 {
+			t.Fatalf("parseSpec(%q) 件数无效: %+v", spec, info)
+		}
+	})
+}
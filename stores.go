@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// AllStores 查询参数中表示所有门店
+const AllStores = "all"
+
+// StoreConfig 门店配置，同一网站不同门店的价格由Cookie区分
+type StoreConfig struct {
+	ID      string            `json:"id"`                // 门店ID，凤展超市为 scsmdid，如 012
+	Name    string            `json:"name"`              // 门店名称，如 凤展超市文华路店
+	Area    string            `json:"area,omitempty"`    // 所在区域，如 文华路
+	Cookies map[string]string `json:"cookies,omitempty"` // 额外覆盖的Cookie，优先于按门店生成的Cookie
+}
+
+// storeAdapter 可选接口，按门店生成Cookie的适配器实现
+type storeAdapter interface {
+	// StoreCookies 返回选择该门店所需的Cookie，值为编码后的Cookie值
+	StoreCookies(s StoreConfig) map[string]string
+}
+
+// validateStores 检查门店配置
+func (c *Config) validateStores() error {
+	seen := make(map[string]bool)
+	for i, store := range c.Stores {
+		if store.ID == "" {
+			return fmt.Errorf("第%d个门店缺少id", i+1)
+		}
+		if store.ID == AllStores || strings.ContainsAny(store.ID, ",|@") {
+			return fmt.Errorf("门店id不能是 %s 或包含 , | @: %s", AllStores, store.ID)
+		}
+		if seen[store.ID] {
+			return fmt.Errorf("门店id重复: %s", store.ID)
+		}
+		seen[store.ID] = true
+		if store.Name == "" {
+			return fmt.Errorf("门店 %s 缺少name", store.ID)
+		}
+	}
+	for _, category := range c.Categories {
+		if _, ok := c.findStore(category.Store); category.Store != "" && !ok {
+			return fmt.Errorf("分类 %s 的门店不存在: %s", category.ID, category.Store)
+		}
+	}
+	return nil
+}
+
+// findStore 按ID查找门店
+func (c *Config) findStore(id string) (StoreConfig, bool) {
+	for _, store := range c.Stores {
+		if store.ID == id {
+			return store, true
+		}
+	}
+	return StoreConfig{}, false
+}
+
+// selectStores 解析门店参数：为空时为第一个门店，all 为所有门店，也可以用逗号分隔多个门店ID。
+// 没有配置门店时返回nil，按 cookie 中的门店抓取
+func (c *Config) selectStores(param string) ([]StoreConfig, error) {
+	param = strings.TrimSpace(param)
+	if len(c.Stores) == 0 {
+		if param != "" && param != AllStores {
+			return nil, fmt.Errorf("没有配置门店，无法选择门店 %s", param)
+		}
+		return nil, nil
+	}
+	switch param {
+	case "":
+		return c.Stores[:1], nil
+	case AllStores:
+		return c.Stores, nil
+	}
+
+	var stores []StoreConfig
+	for _, id := range strings.Split(param, ",") {
+		store, ok := c.findStore(strings.TrimSpace(id))
+		if !ok {
+			return nil, fmt.Errorf("门店不存在: %s", id)
+		}
+		stores = append(stores, store)
+	}
+	return stores, nil
+}
+
+// storeParam 选择的门店对应的参数值，与 selectStores 相反
+func storeParam(config Config, stores []StoreConfig) string {
+	if len(stores) == 0 {
+		return ""
+	}
+	if len(stores) > 1 && len(stores) == len(config.Stores) {
+		return AllStores
+	}
+	ids := make([]string, len(stores))
+	for i, store := range stores {
+		ids[i] = store.ID
+	}
+	return strings.Join(ids, ",")
+}
+
+// publicStores 用于页面和接口输出的门店列表，不包含Cookie
+func publicStores(stores []StoreConfig) []StoreConfig {
+	public := make([]StoreConfig, len(stores))
+	for i, store := range stores {
+		store.Cookies = nil
+		public[i] = store
+	}
+	return public
+}
+
+// storeName 门店名称，未配置门店时为空
+func storeName(config Config, id string) string {
+	store, _ := config.findStore(id)
+	return store.Name
+}
+
+// storeCategories 每个门店的启用分类，设置了门店的分类只在该门店抓取
+func storeCategories(config Config, stores []StoreConfig) []CategoryConfig {
+	categories := config.EnabledCategories()
+	if len(stores) == 0 {
+		return categories
+	}
+	result := make([]CategoryConfig, 0, len(stores)*len(categories))
+	for _, store := range stores {
+		for _, c := range categories {
+			if c.Store != "" && c.Store != store.ID {
+				continue
+			}
+			c.Store = store.ID
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// storeCookie 门店使用的Cookie：在配置的cookie上依次覆盖适配器按门店生成的Cookie和门店配置的cookies
+func storeCookie(base string, adapter SiteAdapter, s StoreConfig) string {
+	if sa, ok := adapter.(storeAdapter); ok {
+		base = mergeCookies(base, sa.StoreCookies(s))
+	}
+	return mergeCookies(base, s.Cookies)
+}
+
+// mergeCookies 覆盖cookie字符串中的同名Cookie，保持原有顺序，新增的按名称排序追加在后面
+func mergeCookies(base string, overrides map[string]string) string {
+	if len(overrides) == 0 {
+		return base
+	}
+
+	var parts []string
+	replaced := make(map[string]bool)
+	for _, part := range strings.Split(base, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(part, "=")
+		if value, ok := overrides[strings.TrimSpace(name)]; ok {
+			part = strings.TrimSpace(name) + "=" + value
+			replaced[strings.TrimSpace(name)] = true
+		}
+		parts = append(parts, part)
+	}
+
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		if !replaced[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, name+"="+overrides[name])
+	}
+	return strings.Join(parts, "; ")
+}
+
+// StoreCookies 凤展超市按 scsmdid 区分门店，shdzmdname、shdzarea 为URL编码的门店名称和区域
+func (fengzhansyAdapter) StoreCookies(s StoreConfig) map[string]string {
+	cookies := map[string]string{
+		"scsmdid":    s.ID,
+		"shdzmdname": url.QueryEscape(s.Name),
+	}
+	if s.Area != "" {
+		cookies["shdzarea"] = url.QueryEscape(s.Area)
+	}
+	return cookies
+}
+
+// MultiStore 页面中是否有多个门店的数据，此时分类标签显示门店名称
+func (d PageData) MultiStore() bool {
+	seen := make(map[string]bool)
+	for _, c := range d.Categories {
+		seen[c.Store] = true
+	}
+	return len(seen) > 1
+}
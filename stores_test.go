@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStores 测试门店Cookie、门店选择、多门店并发抓取以及按门店分开记录历史
+func TestStores(t *testing.T) {
+	fz, _ := getAdapter("")
+	store := StoreConfig{ID: "015", Name: "凤展超市泽州路店", Area: "泽州路", Cookies: map[string]string{"token": "t1"}}
+	cookie := storeCookie("shdzarea=x; scsmdid=012; session=s; shdzmdname=y", fz, store)
+	want := "shdzarea=" + url.QueryEscape("泽州路") + "; scsmdid=015; session=s; shdzmdname=" + url.QueryEscape("凤展超市泽州路店") + "; token=t1"
+	if cookie != want {
+		t.Errorf("storeCookie = %s, 期望 %s", cookie, want)
+	}
+
+	config := Config{Stores: []StoreConfig{{ID: "012", Name: "文华路店"}, {ID: "015", Name: "泽州路店"}}}
+	selectCases := []struct {
+		param string
+		want  string
+	}{
+		{"", "012"},
+		{"all", "all"},
+		{"015", "015"},
+		{"015, 012", "all"},
+	}
+	for _, tc := range selectCases {
+		stores, err := config.selectStores(tc.param)
+		if err != nil || storeParam(config, stores) != tc.want {
+			t.Errorf("selectStores(%q) = %+v, err=%v, 期望 %s", tc.param, stores, err, tc.want)
+		}
+	}
+	if _, err := config.selectStores("999"); err == nil {
+		t.Error("不存在的门店应返回错误")
+	}
+	if stores, err := (&Config{}).selectStores(""); err != nil || stores != nil {
+		t.Errorf("未配置门店时 selectStores = %+v, err=%v", stores, err)
+	}
+
+	// 不同门店的价格由Cookie区分
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		price := "3.98"
+		if c, err := r.Cookie("scsmdid"); err == nil && c.Value == "015" {
+			price = "2.98"
+		}
+		fmt.Fprintf(w, `<div class="index_picAD"><div><a href="p1"></a><h3>西红柿</h3><span class="price">￥%s</span><span class="spec">500g</span></div></div>`, price)
+	}))
+	defer server.Close()
+
+	config.Cookie = "scsmdid=012"
+	config.Timeout = 5
+	config.Categories = []CategoryConfig{
+		{ID: "fruit-vegetable", Name: "瓜果花菜类", URL: server.URL},
+		{ID: "leaf-vegetable", Name: "叶菜类", URL: server.URL, Store: "015"},
+	}
+	stores, _ := config.selectStores(AllStores)
+	data := fetchStoresConcurrently(context.Background(), config, stores)
+	var got []string
+	for _, c := range data.Categories {
+		if c.Status != CategoryStatusOK || len(c.Products) != 1 {
+			t.Fatalf("分类 %s@%s 抓取结果 status=%s error=%s", c.ID, c.Store, c.Status, c.Error)
+		}
+		p := c.Products[0]
+		got = append(got, fmt.Sprintf("%s@%s:%s:%.2f", c.ID, p.Store, p.StoreName, p.Price))
+	}
+	wantCategories := "fruit-vegetable@012:文华路店:3.98,fruit-vegetable@015:泽州路店:2.98,leaf-vegetable@015:泽州路店:2.98"
+	if strings.Join(got, ",") != wantCategories {
+		t.Errorf("多门店抓取结果 = %v, 期望 %s", got, wantCategories)
+	}
+	if data.Store != AllStores || len(data.Stores) != 2 || !data.MultiStore() {
+		t.Errorf("页面门店 store=%s stores=%+v", data.Store, data.Stores)
+	}
+
+	out, err := renderProductList("", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`href="?store=015&unit=jin"`, `<span class="store-name">泽州路店</span>`, `href="?unit=kg&store=all"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("页面中缺少 %q", want)
+		}
+	}
+
+	// 同一分类不同门店的历史分开记录，一个门店没有的商品不算另一个门店下架
+	history, err := openHistoryStore(HistoryConfig{Driver: HistoryDriverJSONL, Path: filepath.Join(t.TempDir(), "history.jsonl")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer history.Close()
+	day1 := time.Date(2025, 3, 1, 8, 0, 0, 0, time.Local)
+	history.Record("fruit-vegetable", "012", data.Categories[0].Products, day1)
+	history.Record("fruit-vegetable", "015", data.Categories[1].Products, day1)
+	if n, _ := history.Record("fruit-vegetable", "015", nil, day1.Add(time.Hour)); n != 1 {
+		t.Errorf("015 下架记录写入 %d 条, 期望1条", n)
+	}
+	observations, _ := history.Query(HistoryQuery{})
+	state := replayHistory(observations)
+	if len(state) != 1 || state["fruit-vegetable@012|p1"].Price != 3.98 {
+		t.Errorf("回放结果 = %+v, 期望只剩 012 的西红柿", state)
+	}
+	if observations, _ := history.Query(HistoryQuery{Store: "015"}); len(observations) != 2 {
+		t.Errorf("按门店查询到 %d 条记录, 期望2条", len(observations))
+	}
+}
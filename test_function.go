@@ -105,12 +105,12 @@ func testMain() {
 
 	fmt.Println("=== 阿里云函数测试客户端 ===")
 	fmt.Printf("函数URL: %s\n", functionURL)
-	fmt.Printf("目标URL: %s\n", config.UrlFv)
+	fmt.Printf("目标URL: %s\n", categoryURL(config.DefaultCategory()))
 	fmt.Println("")
 
 	// 创建测试客户端
 	client := NewFunctionTestClient(functionURL)
 
 	// 测试所有模式
-	client.TestAllModes(config.UrlFv, config.Cookie)
+	client.TestAllModes(categoryURL(config.DefaultCategory()), config.Cookie)
 }
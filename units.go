@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// gramsPerJin 一斤的克数，每斤价格与每克价格按此换算
+const gramsPerJin = 500
+
+// unitPerJin 按重量计算的商品的价格单位
+const unitPerJin = "元/斤"
+
+// PriceUnit 重量商品价格的显示单位
+type PriceUnit struct {
+	Key   string  `json:"key"`   // 单位标识，用于查询参数和配置：jin / kg / 500g / 100g
+	Label string  `json:"label"` // 显示名称，如 斤、公斤
+	Grams float64 `json:"grams"` // 一个单位的克数
+}
+
+// priceUnits 支持的显示单位，第一个为默认单位
+var priceUnits = []PriceUnit{
+	{Key: "jin", Label: "斤", Grams: gramsPerJin},
+	{Key: "kg", Label: "公斤", Grams: 1000},
+	{Key: "500g", Label: "500g", Grams: 500},
+	{Key: "100g", Label: "100g", Grams: 100},
+}
+
+// defaultPriceUnit 默认按斤显示
+var defaultPriceUnit = priceUnits[0]
+
+// priceUnitAliases 单位的其他写法
+var priceUnitAliases = map[string]string{
+	"斤": "jin", "公斤": "kg", "千克": "kg",
+}
+
+// parsePriceUnit 解析单位标识或名称，为空时返回默认单位
+func parsePriceUnit(s string) (PriceUnit, error) {
+	key := strings.ToLower(strings.TrimSpace(s))
+	if key == "" {
+		return defaultPriceUnit, nil
+	}
+	if alias, ok := priceUnitAliases[key]; ok {
+		key = alias
+	}
+	for _, u := range priceUnits {
+		if u.Key == key {
+			return u, nil
+		}
+	}
+	keys := make([]string, len(priceUnits))
+	for i, u := range priceUnits {
+		keys[i] = u.Key
+	}
+	return PriceUnit{}, fmt.Errorf("不支持的价格单位 %q，支持: %s", s, strings.Join(keys, "、"))
+}
+
+// orDefault 未设置单位时使用默认单位
+func (u PriceUnit) orDefault() PriceUnit {
+	if u.Grams <= 0 {
+		return defaultPriceUnit
+	}
+	return u
+}
+
+// Text 价格单位的显示文本，如 元/公斤
+func (u PriceUnit) Text() string {
+	return "元/" + u.Name()
+}
+
+// Name 单位的显示名称，如 公斤
+func (u PriceUnit) Name() string {
+	return u.orDefault().Label
+}
+
+// FromPerGram 每克价格换算为该单位的价格
+func (u PriceUnit) FromPerGram(perGram float64) float64 {
+	return perGram * u.orDefault().Grams
+}
+
+// FromPerJin 每斤价格换算为该单位的价格
+func (u PriceUnit) FromPerJin(perJin float64) float64 {
+	return perJin * u.orDefault().Grams / gramsPerJin
+}
+
+// Convert 换算商品的价格，单位为 元/斤 时按重量换算，包装价格不变
+func (u PriceUnit) Convert(value float64, unit string) float64 {
+	if unit != unitPerJin {
+		return value
+	}
+	return u.FromPerJin(value)
+}
+
+// Display 价格单位的显示文本，单位为 元/斤 时显示选择的单位，包装价格显示原单位
+func (u PriceUnit) Display(unit string) string {
+	if unit != unitPerJin {
+		return displayUnit(unit)
+	}
+	return u.Text()
+}
+
+// pricePerGram 每斤价格对应的每克价格
+func pricePerGram(perJin float64) float64 {
+	return perJin / gramsPerJin
+}
+
+// priceUnitFor 请求中指定了单位时使用请求的单位，否则使用配置中的默认单位
+func priceUnitFor(config Config, requested string) (PriceUnit, error) {
+	if strings.TrimSpace(requested) == "" {
+		requested = config.PriceUnit
+	}
+	return parsePriceUnit(requested)
+}
+
+// PriceUnits 页面中可选的价格单位
+func (d PageData) PriceUnits() []PriceUnit {
+	return priceUnits
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestPriceUnits 测试价格单位的解析、换算和页面显示
+func TestPriceUnits(t *testing.T) {
+	tests := []struct {
+		unit   string
+		key    string
+		perJin float64 // 3.98元/斤换算后的价格
+		text   string
+	}{
+		{"", "jin", 3.98, "元/斤"},
+		{"斤", "jin", 3.98, "元/斤"},
+		{"KG", "kg", 7.96, "元/公斤"},
+		{"千克", "kg", 7.96, "元/公斤"},
+		{"500g", "500g", 3.98, "元/500g"},
+		{"100g", "100g", 0.796, "元/100g"},
+	}
+	for _, tc := range tests {
+		u, err := parsePriceUnit(tc.unit)
+		if err != nil {
+			t.Errorf("parsePriceUnit(%q) 返回错误: %v", tc.unit, err)
+			continue
+		}
+		if u.Key != tc.key || math.Abs(u.Convert(3.98, "元/斤")-tc.perJin) > 1e-9 || u.Display("元/斤") != tc.text {
+			t.Errorf("parsePriceUnit(%q) = %+v, 换算 %v%s, 期望 %s %v%s", tc.unit, u, u.Convert(3.98, "元/斤"), u.Display("元/斤"), tc.key, tc.perJin, tc.text)
+		}
+		if math.Abs(u.FromPerGram(3.98/500)-tc.perJin) > 1e-9 {
+			t.Errorf("%s: 每克价格换算 = %v, 期望 %v", tc.key, u.FromPerGram(3.98/500), tc.perJin)
+		}
+		// 包装价格不换算
+		if u.Convert(9.9, "元/盒") != 9.9 || u.Display("元/盒") != "元/盒" {
+			t.Errorf("%s: 包装价格不应换算", tc.key)
+		}
+	}
+	if _, err := parsePriceUnit("两"); err == nil {
+		t.Error("parsePriceUnit(两) 应返回错误")
+	}
+	if u, err := priceUnitFor(Config{PriceUnit: "kg"}, ""); err != nil || u.Key != "kg" {
+		t.Errorf("priceUnitFor 未使用配置中的默认单位: %+v, %v", u, err)
+	}
+	if u, err := priceUnitFor(Config{PriceUnit: "kg"}, "100g"); err != nil || u.Key != "100g" {
+		t.Errorf("priceUnitFor 未使用请求中的单位: %+v, %v", u, err)
+	}
+
+	p := Product{ID: "1", Name: "蒜苔", Price: 3.98, Spec: "500g", OriginalPrice: 5.98}
+	p.updatePricePerJin()
+	if math.Abs(p.PricePerGram-3.98/500) > 1e-12 {
+		t.Errorf("PricePerGram = %v", p.PricePerGram)
+	}
+	kg, _ := parsePriceUnit("kg")
+	html, err := renderProductList("", PageData{
+		Categories: []Category{{ID: "fv", Name: "瓜果", Products: []Product{p}, Status: CategoryStatusOK}},
+		PriceUnit:  kg,
+	})
+	if err != nil {
+		t.Fatalf("renderProductList 返回错误: %v", err)
+	}
+	for _, want := range []string{"7.96元/公斤", "11.96元/公斤", "每公斤省 4.00元", `href="?unit=kg" class="unit-option active"`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("页面中缺少 %q", want)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// WatchlistConfig 服务端收藏夹配置
+type WatchlistConfig struct {
+	Path string   `json:"path"`          // 收藏夹存储文件
+	IDs  []string `json:"ids,omitempty"` // 允许使用的收藏夹ID，为空时任何符合格式的ID都可以使用
+}
+
+// 收藏夹接口的限制，避免请求写入任意多的数据
+const (
+	maxWatchlistProducts    = 500      // 每个收藏夹最多收藏的商品数
+	maxWatchlists           = 100      // 没有配置 ids 时最多保存的收藏夹数
+	maxWatchlistRequestSize = 64 << 10 // 收藏和导入请求体的最大字节数
+)
+
+// watchlistLimitError 收藏夹数量或收藏的商品数超出限制
+type watchlistLimitError string
+
+func (e watchlistLimitError) Error() string { return string(e) }
+
+// watchlistIDPattern 收藏夹ID的格式，收藏夹ID同时是访问凭证，建议使用足够长的随机字符串
+var watchlistIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{4,64}$`)
+
+// checkWatchlistID 检查收藏夹ID的格式，配置了 ids 时只能使用其中的ID
+func (w WatchlistConfig) checkWatchlistID(id string) error {
+	if !watchlistIDPattern.MatchString(id) {
+		return fmt.Errorf("收藏夹ID只能包含字母、数字、- 和 _，长度4-64: %q", id)
+	}
+	if len(w.IDs) == 0 {
+		return nil
+	}
+	for _, allowed := range w.IDs {
+		if allowed == id {
+			return nil
+		}
+	}
+	return fmt.Errorf("收藏夹不存在: %s", id)
+}
+
+// validateWatchlists 检查收藏夹配置和提醒规则引用的收藏夹
+func (c *Config) validateWatchlists() error {
+	for _, id := range c.Watchlists.IDs {
+		if !watchlistIDPattern.MatchString(id) {
+			return fmt.Errorf("收藏夹ID只能包含字母、数字、- 和 _，长度4-64: %q", id)
+		}
+	}
+	for _, rule := range c.Alerts.Rules {
+		if rule.Watchlist == "" {
+			continue
+		}
+		if err := c.Watchlists.checkWatchlistID(rule.Watchlist); err != nil {
+			return fmt.Errorf("提醒规则 %s 的watchlist无效: %v", rule.ID, err)
+		}
+	}
+	return nil
+}
+
+// WatchedProduct 收藏的商品，名称等信息为收藏时的值，商品下架后仍可显示
+type WatchedProduct struct {
+	ProductID    string    `json:"product_id"`
+	Name         string    `json:"name,omitempty"`
+	CanonicalKey string    `json:"canonical_key,omitempty"`
+	CategoryID   string    `json:"category_id,omitempty"`
+	Store        string    `json:"store,omitempty"` // 收藏时所在的门店
+	AddedAt      time.Time `json:"added_at"`
+}
+
+// Watchlist 一个收藏夹，同一家庭的多台设备使用同一个收藏夹ID
+type Watchlist struct {
+	ID        string           `json:"id"`
+	Products  []WatchedProduct `json:"products"` // 按收藏时间排列
+	UpdatedAt time.Time        `json:"updated_at,omitzero"`
+}
+
+// ProductIDs 收藏的商品ID，用于页面中标记收藏状态
+func (w Watchlist) ProductIDs() []string {
+	ids := make([]string, len(w.Products))
+	for i, p := range w.Products {
+		ids[i] = p.ProductID
+	}
+	return ids
+}
+
+// add 添加商品，已收藏的商品只更新信息，返回新增的数量。
+// 添加后超过 maxWatchlistProducts 时不做修改并返回错误
+func (w *Watchlist) add(products []WatchedProduct, at time.Time) (int, error) {
+	seen := make(map[string]bool)
+	for _, p := range products {
+		if p.ProductID != "" && w.index(p.ProductID) < 0 {
+			seen[p.ProductID] = true
+		}
+	}
+	if len(w.Products)+len(seen) > maxWatchlistProducts {
+		return 0, watchlistLimitError(fmt.Sprintf("每个收藏夹最多收藏%d个商品", maxWatchlistProducts))
+	}
+
+	added := 0
+	for _, p := range products {
+		if p.ProductID == "" {
+			continue
+		}
+		if i := w.index(p.ProductID); i >= 0 {
+			p.AddedAt = w.Products[i].AddedAt
+			if p.Name == "" {
+				// 商品不在当前页面中时保留收藏时的信息
+				p = w.Products[i]
+			}
+			w.Products[i] = p
+			continue
+		}
+		p.AddedAt = at
+		w.Products = append(w.Products, p)
+		added++
+	}
+	return added, nil
+}
+
+// remove 取消收藏，商品不在收藏夹中时返回false
+func (w *Watchlist) remove(productID string) bool {
+	i := w.index(productID)
+	if i < 0 {
+		return false
+	}
+	w.Products = append(w.Products[:i], w.Products[i+1:]...)
+	return true
+}
+
+func (w *Watchlist) index(productID string) int {
+	for i, p := range w.Products {
+		if p.ProductID == productID {
+			return i
+		}
+	}
+	return -1
+}
+
+// watchlistFile 收藏夹存储文件的内容
+type watchlistFile struct {
+	Lists map[string]*Watchlist `json:"lists"` // 收藏夹ID -> 收藏夹
+}
+
+// loadWatchlists 读取收藏夹文件，文件不存在时返回空内容
+func loadWatchlists(path string) (*watchlistFile, error) {
+	file := &watchlistFile{Lists: make(map[string]*Watchlist)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取收藏夹失败: %v", err)
+	}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("解析收藏夹失败: %v", err)
+	}
+	if file.Lists == nil {
+		file.Lists = make(map[string]*Watchlist)
+	}
+	return file, nil
+}
+
+// save 保存收藏夹文件
+func (f *watchlistFile) save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("创建收藏夹目录失败: %v", err)
+	}
+	// 先写临时文件再重命名，避免写入中断损坏收藏夹
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("保存收藏夹失败: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// get 按ID获取收藏夹，不存在时返回空收藏夹
+func (f *watchlistFile) get(id string) Watchlist {
+	if list, ok := f.Lists[id]; ok {
+		return *list
+	}
+	return Watchlist{ID: id, Products: make([]WatchedProduct, 0)}
+}
+
+// watchlistMu 保证同一进程内收藏夹文件的读写不会交错
+var watchlistMu sync.Mutex
+
+// getWatchlist 读取收藏夹，还没有收藏过商品时返回空收藏夹
+func getWatchlist(config WatchlistConfig, id string) (Watchlist, error) {
+	if err := config.checkWatchlistID(id); err != nil {
+		return Watchlist{}, err
+	}
+	watchlistMu.Lock()
+	defer watchlistMu.Unlock()
+
+	file, err := loadWatchlists(config.Path)
+	if err != nil {
+		return Watchlist{}, err
+	}
+	return file.get(id), nil
+}
+
+// updateWatchlist 修改收藏夹并保存，返回修改后的收藏夹；update 返回错误时不保存。
+// 修改后为空的收藏夹从文件中删除，没有配置 ids 时最多保存 maxWatchlists 个收藏夹
+func updateWatchlist(config WatchlistConfig, id string, update func(w *Watchlist) error) (Watchlist, error) {
+	if err := config.checkWatchlistID(id); err != nil {
+		return Watchlist{}, err
+	}
+	watchlistMu.Lock()
+	defer watchlistMu.Unlock()
+
+	file, err := loadWatchlists(config.Path)
+	if err != nil {
+		return Watchlist{}, err
+	}
+	list := file.get(id)
+	if err := update(&list); err != nil {
+		return Watchlist{}, err
+	}
+	list.UpdatedAt = time.Now()
+	_, exists := file.Lists[id]
+	switch {
+	case len(list.Products) == 0:
+		delete(file.Lists, id)
+	case !exists && len(config.IDs) == 0 && len(file.Lists) >= maxWatchlists:
+		return Watchlist{}, watchlistLimitError(fmt.Sprintf("收藏夹数量已达上限%d个", maxWatchlists))
+	default:
+		file.Lists[id] = &list
+	}
+	if err := file.save(config.Path); err != nil {
+		return Watchlist{}, err
+	}
+	return list, nil
+}
+
+// watchedProducts 按商品ID从页面数据中补全收藏商品的名称、规范名称、分类和门店，
+// 页面中没有的商品（如已下架或在其他门店）只保留商品ID
+func watchedProducts(data PageData, ids []string) []WatchedProduct {
+	found := make(map[string]WatchedProduct)
+	for _, c := range data.Categories {
+		for _, p := range c.Products {
+			if _, ok := found[p.ID]; ok {
+				continue
+			}
+			found[p.ID] = WatchedProduct{ProductID: p.ID, Name: p.Name, CanonicalKey: p.CanonicalKey, CategoryID: c.ID, Store: c.Store}
+		}
+	}
+
+	products := make([]WatchedProduct, 0, len(ids))
+	for _, id := range ids {
+		if p, ok := found[id]; ok {
+			products = append(products, p)
+		} else if id != "" {
+			products = append(products, WatchedProduct{ProductID: id})
+		}
+	}
+	return products
+}
+
+// WatchlistItem 收藏的商品及其当前价格
+type WatchlistItem struct {
+	WatchedProduct
+	StoreName string   `json:"store_name,omitempty"`
+	Current   *Product `json:"current"` // 当前的商品信息，页面中没有时为null
+}
+
+// WatchlistView 收藏夹及收藏商品的当前价格
+type WatchlistView struct {
+	ID        string          `json:"id"`
+	Products  []WatchlistItem `json:"products"`
+	UpdatedAt time.Time       `json:"updated_at,omitzero"`
+}
+
+// watchlistView 收藏夹中的商品在页面数据中的当前价格，同一商品在多个门店时优先收藏时的门店
+func watchlistView(config Config, w Watchlist, data PageData) WatchlistView {
+	view := WatchlistView{ID: w.ID, Products: make([]WatchlistItem, 0, len(w.Products)), UpdatedAt: w.UpdatedAt}
+	for _, watched := range w.Products {
+		item := WatchlistItem{WatchedProduct: watched, StoreName: storeName(config, watched.Store)}
+		for _, c := range data.Categories {
+			for i := range c.Products {
+				if c.Products[i].ID != watched.ProductID {
+					continue
+				}
+				if item.Current == nil || c.Store == watched.Store {
+					p := c.Products[i]
+					item.Current = &p
+				}
+			}
+		}
+		view.Products = append(view.Products, item)
+	}
+	return view
+}
+
+// withWatchlists 为引用收藏夹的提醒规则设置收藏的商品ID，这些规则只检查收藏的商品
+func withWatchlists(config WatchlistConfig, rules []AlertRule) ([]AlertRule, error) {
+	needed := false
+	for _, rule := range rules {
+		needed = needed || rule.Watchlist != ""
+	}
+	if !needed {
+		return rules, nil
+	}
+
+	watchlistMu.Lock()
+	file, err := loadWatchlists(config.Path)
+	watchlistMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make([]AlertRule, len(rules))
+	for i, rule := range rules {
+		if rule.Watchlist != "" {
+			rule.watched = make(map[string]bool)
+			for _, p := range file.get(rule.Watchlist).Products {
+				rule.watched[p.ProductID] = true
+			}
+		}
+		resolved[i] = rule
+	}
+	return resolved, nil
+}
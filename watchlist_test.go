@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWatchlists 测试服务端收藏夹的收藏、取消、导入、按收藏夹提醒和页面中的收藏状态
+func TestWatchlists(t *testing.T) {
+	config := WatchlistConfig{Path: filepath.Join(t.TempDir(), "watchlists.json")}
+	for _, id := range []string{"abc", "home 1", "../x", strings.Repeat("a", 65)} {
+		if err := config.checkWatchlistID(id); err == nil {
+			t.Errorf("收藏夹ID %q 应校验失败", id)
+		}
+	}
+	if err := (WatchlistConfig{IDs: []string{"home1"}}).checkWatchlistID("home2"); err == nil {
+		t.Error("配置了 ids 时其他收藏夹ID应校验失败")
+	}
+
+	data := PageData{Categories: []Category{
+		{ID: "fruit-vegetable", Store: "012", Status: CategoryStatusOK, Products: []Product{
+			{ID: "p1", Name: "【精品】番茄", CanonicalKey: "西红柿", Price: 3.98, PricePerJin: 3.98, Unit: "元/斤"},
+			{ID: "p2", Name: "黄瓜", CanonicalKey: "黄瓜", Price: 2.5, PricePerJin: 2.5, Unit: "元/斤"},
+		}},
+	}}
+
+	// 还没有收藏过的收藏夹为空
+	list, err := getWatchlist(config, "home1")
+	if err != nil || list.ID != "home1" || len(list.Products) != 0 {
+		t.Fatalf("getWatchlist = %+v, %v, 期望空收藏夹", list, err)
+	}
+
+	added := 0
+	list, err = updateWatchlist(config, "home1", func(w *Watchlist) (err error) {
+		added, err = w.add(watchedProducts(data, []string{"p1", "gone"}), time.Now())
+		return err
+	})
+	if err != nil || added != 2 {
+		t.Fatalf("收藏 added=%d err=%v, 期望新增2个", added, err)
+	}
+	if p := list.Products[0]; p.Name != "【精品】番茄" || p.CanonicalKey != "西红柿" || p.CategoryID != "fruit-vegetable" || p.Store != "012" {
+		t.Errorf("收藏的商品信息 = %+v", p)
+	}
+	if list.Products[1].Name != "" {
+		t.Errorf("页面中没有的商品只保留商品ID: %+v", list.Products[1])
+	}
+
+	// 导入本地收藏：已收藏的不重复添加，商品不在页面中时保留收藏时的信息
+	list, err = updateWatchlist(config, "home1", func(w *Watchlist) (err error) {
+		added, err = w.add(watchedProducts(PageData{}, []string{"p1", "p2"}), time.Now())
+		return err
+	})
+	if err != nil || added != 1 || len(list.Products) != 3 || list.Products[0].Name != "【精品】番茄" {
+		t.Errorf("导入 added=%d err=%v products=%+v", added, err, list.Products)
+	}
+
+	removed := false
+	list, err = updateWatchlist(config, "home1", func(w *Watchlist) error { removed = w.remove("gone"); return nil })
+	if err != nil || !removed {
+		t.Fatalf("取消收藏 removed=%v err=%v", removed, err)
+	}
+	// 重新读取后与保存的一致，其他收藏夹不受影响
+	if list, _ = getWatchlist(config, "home1"); strings.Join(list.ProductIDs(), ",") != "p1,p2" {
+		t.Errorf("重新读取的收藏 = %v, 期望 p1,p2", list.ProductIDs())
+	}
+	other, _ := getWatchlist(config, "home2")
+	if len(other.Products) != 0 {
+		t.Errorf("其他收藏夹 = %+v, 期望为空", other)
+	}
+	// 没有修改过的收藏夹不输出 updated_at
+	for _, v := range []interface{}{other, watchlistView(Config{}, other, PageData{})} {
+		if body, _ := json.Marshal(v); strings.Contains(string(body), "updated_at") {
+			t.Errorf("空收藏夹的JSON = %s, 不应包含 updated_at", body)
+		}
+	}
+
+	view := watchlistView(Config{}, list, data)
+	if len(view.Products) != 2 || view.Products[0].Current == nil || view.Products[0].Current.Price != 3.98 {
+		t.Errorf("收藏商品的当前价格 = %+v", view.Products)
+	}
+
+	// 引用收藏夹的提醒规则只检查收藏的商品
+	rules, err := withWatchlists(config, []AlertRule{{ID: "cheap", Op: "<", Value: 5, Watchlist: "home1"}, {ID: "empty", Op: "<", Value: 5, Watchlist: "home2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data.Categories[0].Products = append(data.Categories[0].Products, Product{ID: "p3", Name: "土豆", PricePerJin: 1.5, Unit: "元/斤"})
+	var keys []string
+	for _, a := range evaluateAlerts(rules, data.Categories, nil, time.Now()) {
+		keys = append(keys, a.Key())
+	}
+	if got := strings.Join(keys, ","); got != "cheap|fruit-vegetable@012|p1,cheap|fruit-vegetable@012|p2" {
+		t.Errorf("触发的提醒 = %s", got)
+	}
+
+	// 页面从服务端收藏夹读取收藏，收藏时带上当前门店
+	data.PriceUnit = defaultPriceUnit
+	data.Watchlist = &list
+	data.Store = "012"
+	data.WatchlistSync = true
+	out, err := renderProductList("", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`const watchlistID = "home1";`, `let serverFavorites = ["p1","p2"];`, "已同步到收藏夹 home1", `const currentStore = "012";`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("页面中缺少 %q", want)
+		}
+	}
+	// 不是 serve 模式时没有收藏夹接口，收藏保存在浏览器本地
+	data.WatchlistSync = false
+	if out, err = renderProductList("", data); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "const watchlistID = '';") || strings.Contains(out, "已同步到收藏夹") || strings.Contains(out, `name="watchlist"`) {
+		t.Error("没有收藏夹接口时页面不应显示同步入口")
+	}
+
+	// 每个收藏夹的商品数量有上限，超出时不修改
+	var many []WatchedProduct
+	for i := 0; i <= maxWatchlistProducts; i++ {
+		many = append(many, WatchedProduct{ProductID: fmt.Sprintf("m%d", i)})
+	}
+	full := Watchlist{ID: "home1"}
+	if _, err := full.add(many, time.Now()); err == nil || len(full.Products) != 0 {
+		t.Errorf("超出商品数上限应返回错误且不修改, err=%v products=%d", err, len(full.Products))
+	}
+	if added, err := full.add(many[:maxWatchlistProducts], time.Now()); err != nil || added != maxWatchlistProducts {
+		t.Errorf("达到上限时 added=%d err=%v", added, err)
+	}
+
+	// 清空后的收藏夹从文件中删除；没有配置 ids 时收藏夹数量有上限
+	if _, err := updateWatchlist(config, "home1", func(w *Watchlist) error { w.Products = nil; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if file, _ := loadWatchlists(config.Path); len(file.Lists) != 0 {
+		t.Errorf("清空后的收藏夹应删除: %+v", file.Lists)
+	}
+	addOne := func(w *Watchlist) error {
+		_, err := w.add([]WatchedProduct{{ProductID: "p1"}}, time.Now())
+		return err
+	}
+	for i := 0; i < maxWatchlists; i++ {
+		if _, err := updateWatchlist(config, fmt.Sprintf("list%d", i), addOne); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = updateWatchlist(config, "another", addOne)
+	var limit watchlistLimitError
+	if !errors.As(err, &limit) {
+		t.Errorf("收藏夹数量达到上限时应返回 watchlistLimitError, err=%v", err)
+	}
+	if _, err := updateWatchlist(config, "list0", addOne); err != nil {
+		t.Errorf("已有的收藏夹不受数量上限影响: %v", err)
+	}
+	limited := WatchlistConfig{Path: config.Path, IDs: []string{"another"}}
+	if _, err := updateWatchlist(limited, "another", addOne); err != nil {
+		t.Errorf("配置了 ids 时不限制收藏夹数量: %v", err)
+	}
+
+	// 请求体有大小上限
+	req := httptest.NewRequest(http.MethodPost, "/api/watchlists/home1/import", strings.NewReader(`{"product_ids": ["`+strings.Repeat("a", maxWatchlistRequestSize)+`"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	var body importRequest
+	if readRequest(rec, req, &body, maxWatchlistRequestSize) || rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("超大的请求体应返回413, 实际 %d", rec.Code)
+	}
+}